@@ -0,0 +1,16 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// DebugHeaderAllowed check if requests can enable debug mode with the
+// X-Faas-Flow-Debug header
+func DebugHeaderAllowed() bool {
+	allowed := os.Getenv("allow_debug_header")
+	if strings.ToUpper(allowed) == "TRUE" {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// CheckDeterminism check if the flow definition is verified to be deterministic
+func CheckDeterminism() bool {
+	check := os.Getenv("check_determinism")
+	if strings.ToUpper(check) == "TRUE" {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+package config
+
+import (
+	"os"
+)
+
+// CloudEventsSink return the address lifecycle CloudEvents are sent to,
+// empty if CloudEvents are disabled
+func CloudEventsSink() string {
+	return os.Getenv("cloudevents_sink")
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// CompressDataThreshold return the size in bytes from which data stored in
+// the DataStore is compressed, -1 if compression is disabled
+func CompressDataThreshold() int {
+	threshold, err := strconv.Atoi(os.Getenv("compress_data_threshold"))
+	if err != nil || threshold < 0 {
+		return -1
+	}
+	return threshold
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// DataChunkSize return the size in bytes above which data stored in the
+// DataStore is split into chunks, 0 if chunking is disabled
+func DataChunkSize() int {
+	size, err := strconv.Atoi(os.Getenv("data_chunk_size"))
+	if err != nil || size < 0 {
+		return 0
+	}
+	return size
+}
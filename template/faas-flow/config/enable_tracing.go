@@ -0,0 +1,15 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// TracingEnabled check if request tracing is enabled
+func TracingEnabled() bool {
+	tracing := os.Getenv("enable_tracing")
+	if strings.ToUpper(tracing) == "TRUE" {
+		return true
+	}
+	return false
+}
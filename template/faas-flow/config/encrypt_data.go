@@ -0,0 +1,15 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// EncryptData check if the data stored in the DataStore is encrypted
+func EncryptData() bool {
+	encrypt := os.Getenv("encrypt_data")
+	if strings.ToUpper(encrypt) == "TRUE" {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// FlowDeadline return the default time budget of a request, zero disables it
+func FlowDeadline() time.Duration {
+	return parseIntOrDurationValue(os.Getenv("flow_deadline"), 0)
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	"os"
+)
+
+// FlowOwner return the team owning the flow
+func FlowOwner() string {
+	return os.Getenv("flow_owner")
+}
+
+// FlowEscalation return the escalation channel of the flow owner
+func FlowEscalation() string {
+	return os.Getenv("flow_escalation")
+}
+
+// FlowRunbook return the runbook URL of the flow
+func FlowRunbook() string {
+	return os.Getenv("flow_runbook")
+}
@@ -0,0 +1,10 @@
+package config
+
+import (
+	"os"
+)
+
+// FlowVersion return the version of the flow
+func FlowVersion() string {
+	return os.Getenv("flow_version")
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// ForwardMaxRetries return the number of retries of a throttled async call
+func ForwardMaxRetries() int {
+	retries, err := strconv.Atoi(os.Getenv("forward_max_retries"))
+	if err != nil || retries < 0 {
+		return 5
+	}
+	return retries
+}
+
+// ForwardMaxDelay return the maximum pacing delay of the async calls
+func ForwardMaxDelay() time.Duration {
+	return parseIntOrDurationValue(os.Getenv("forward_max_delay"), 2*time.Second)
+}
+
+// ForwardMaxWait return the maximum total pacing delay of an async call, it
+// is capped to a quarter of the write timeout as the delay is spent in the
+// invocation forwarding the call
+func ForwardMaxWait() time.Duration {
+	limit := WriteTimeout() / 4
+	maxWait := parseIntOrDurationValue(os.Getenv("forward_max_wait"), limit)
+	if maxWait > limit {
+		return limit
+	}
+	return maxWait
+}
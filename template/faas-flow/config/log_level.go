@@ -0,0 +1,15 @@
+package config
+
+import (
+	"os"
+)
+
+// LogLevel return the minimum level of the logs, one of 'debug', 'info'
+// (default), 'warn' or 'error'
+func LogLevel() string {
+	level := os.Getenv("log_level")
+	if level == "" {
+		level = "info"
+	}
+	return level
+}
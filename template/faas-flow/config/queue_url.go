@@ -0,0 +1,11 @@
+package config
+
+import (
+	"os"
+)
+
+// QueueURL return the address of the queue used for async calls, empty if
+// the gateway async-function endpoint is used
+func QueueURL() string {
+	return os.Getenv("queue_url")
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// StampHeaders return the metadata stamped on outgoing requests of the
+// flow, from 'user-agent', 'flow', 'request', 'node', 'attempt' and 'version'
+func StampHeaders() []string {
+	val := os.Getenv("stamp_headers")
+	if len(val) == 0 {
+		return []string{"user-agent", "flow", "request", "node", "attempt", "version"}
+	}
+
+	stamps := []string{}
+	for _, stamp := range strings.Split(val, ",") {
+		stamp = strings.ToLower(strings.TrimSpace(stamp))
+		if stamp != "" && stamp != "none" {
+			stamps = append(stamps, stamp)
+		}
+	}
+	return stamps
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// TracePropagation return the trace context propagation format, one of
+// 'jaeger' (default), 'b3' or 'w3c'
+func TracePropagation() string {
+	propagation := strings.ToLower(os.Getenv("trace_propagation"))
+	if propagation == "" {
+		propagation = "jaeger"
+	}
+	return propagation
+}
@@ -0,0 +1,134 @@
+package eventhandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	hlog "handler/log"
+)
+
+const (
+	RequestStartedEvent   = "com.faasflow.request.started"
+	RequestFinishedEvent  = "com.faasflow.request.finished"
+	RequestFailedEvent    = "com.faasflow.request.failed"
+	NodeCompletedEvent    = "com.faasflow.node.completed"
+	NodeFailedEvent       = "com.faasflow.node.failed"
+	cloudEventSpecVersion = "1.0"
+	// eventBufferSize is the number of events waiting to be sent, further
+	// events are dropped while the buffer is full
+	eventBufferSize = 1000
+)
+
+// pendingEvent is an event waiting to be sent to the sink
+type pendingEvent struct {
+	httpReq   *http.Request
+	requestID string
+	eventType string
+	logger    hlog.Logger
+}
+
+// eventSender sends the events of all requests of the replica in order, out
+// of the request path, so that a slow sink doesn't delay the requests
+var eventSender struct {
+	once   sync.Once
+	events chan pendingEvent
+	client *http.Client
+}
+
+// CloudEventEmitter sends request lifecycle events to a sink as
+// CloudEvents in binary content mode
+type CloudEventEmitter struct {
+	sink      string
+	source    string
+	ownership *Ownership
+	logger    hlog.Logger
+}
+
+// Ownership of the flow, added to failure events for alert routing
+type Ownership struct {
+	Owner      string `json:"owner,omitempty"`
+	Escalation string `json:"escalation,omitempty"`
+	Runbook    string `json:"runbook,omitempty"`
+}
+
+// cloudEventData is the payload of the lifecycle events
+type cloudEventData struct {
+	RequestID string     `json:"request_id"`
+	Node      string     `json:"node,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Ownership *Ownership `json:"ownership,omitempty"`
+}
+
+func newCloudEventEmitter(sink string, flowName string, ownership *Ownership, logger hlog.Logger) *CloudEventEmitter {
+	return &CloudEventEmitter{
+		sink:      sink,
+		source:    "/faas-flow/" + flowName,
+		ownership: ownership,
+		logger:    logger,
+	}
+}
+
+// Emit queues an event of eventType for the request to be sent asynchronously,
+// node and err are optional
+func (emitter *CloudEventEmitter) Emit(eventType string, requestID string, node string, err error) {
+	data := cloudEventData{RequestID: requestID, Node: node}
+	if err != nil {
+		data.Error = err.Error()
+		data.Ownership = emitter.ownership
+	}
+	body, _ := json.Marshal(data)
+
+	now := time.Now().UTC()
+	httpReq, _ := http.NewRequest(http.MethodPost, emitter.sink, bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Ce-Specversion", cloudEventSpecVersion)
+	httpReq.Header.Set("Ce-Id", fmt.Sprintf("%s-%s-%d", requestID, node, now.UnixNano()))
+	httpReq.Header.Set("Ce-Type", eventType)
+	httpReq.Header.Set("Ce-Source", emitter.source)
+	httpReq.Header.Set("Ce-Subject", requestID)
+	httpReq.Header.Set("Ce-Time", now.Format(time.RFC3339Nano))
+	if emitter.ownership != nil && emitter.ownership.Owner != "" {
+		httpReq.Header.Set("Ce-Owner", emitter.ownership.Owner)
+	}
+
+	eventSender.once.Do(startEventSender)
+	select {
+	case eventSender.events <- pendingEvent{httpReq, requestID, eventType, emitter.logger}:
+	default:
+		emitter.logger.Warn("event buffer full, dropping event", hlog.F("request", requestID),
+			hlog.F("type", eventType))
+	}
+}
+
+// startEventSender starts the goroutine sending the queued events
+func startEventSender() {
+	eventSender.events = make(chan pendingEvent, eventBufferSize)
+	eventSender.client = &http.Client{Timeout: 5 * time.Second}
+	go func() {
+		for event := range eventSender.events {
+			sendEvent(event)
+		}
+	}()
+}
+
+// sendEvent posts an event to the sink
+func sendEvent(event pendingEvent) {
+	res, resErr := eventSender.client.Do(event.httpReq)
+	if resErr != nil {
+		event.logger.Error("failed to emit event", hlog.F("request", event.requestID), hlog.F("type", event.eventType),
+			hlog.F("error", resErr))
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resData, _ := ioutil.ReadAll(res.Body)
+		event.logger.Error("failed to emit event", hlog.F("request", event.requestID), hlog.F("type", event.eventType),
+			hlog.F("status", res.StatusCode), hlog.F("response", string(resData)))
+	}
+}
@@ -3,14 +3,26 @@ package eventhandler
 import (
 	"fmt"
 	"net/http"
+	"time"
+
+	"handler/config"
+	"handler/hooks"
+	hlog "handler/log"
 )
 
 // implements faasflow.EventHandler
 type FaasEventHandler struct {
-	CurrentNodeID string        // used to inject current node id in Tracer
-	Tracer        *TraceHandler // handle traces with open-tracing
+	CurrentNodeID string             // used to inject current node id in Tracer
+	Tracer        *TraceHandler      // handle traces with open-tracing, nil if tracing disabled
+	Emitter       *CloudEventEmitter // emit lifecycle CloudEvents, nil if disabled
+	Debug         bool               // log every event and trace the request
+	Hooks         *hooks.Hooks       // lifecycle hooks of the flow, shared by requests
+	Logger        hlog.Logger        // structured logger of the flow
 	flowName      string
 	Header        http.Header
+	StartTime     time.Time       // start time of the request
+	OnFailure     func(err error) // invoked with the error when the request fails
+	nodeStarts    map[string]time.Time
 }
 
 func (eh *FaasEventHandler) Configure(flowName string, requestID string) {
@@ -20,61 +32,173 @@ func (eh *FaasEventHandler) Configure(flowName string, requestID string) {
 func (eh *FaasEventHandler) Init() error {
 	var err error
 
+	eh.Tracer = nil
+	eh.Emitter = nil
+	eh.nodeStarts = make(map[string]time.Time)
+	if eh.Hooks == nil {
+		eh.Hooks = &hooks.Hooks{}
+	}
+
 	// initialize trace server if tracing enabled
-	eh.Tracer, err = initRequestTracer(eh.flowName)
-	if err != nil {
-		return fmt.Errorf("failed to init request Tracer, error %v", err)
+	if config.TracingEnabled() || eh.Debug {
+		eh.Tracer, err = initRequestTracer(eh.flowName, eh.logger())
+		if err != nil {
+			return fmt.Errorf("failed to init request Tracer, error %v", err)
+		}
+	}
+
+	// initialize cloudevents emitter if sink is set
+	if sink := config.CloudEventsSink(); sink != "" {
+		eh.Emitter = newCloudEventEmitter(sink, eh.flowName, FlowOwnership(), eh.logger())
 	}
 	return nil
 }
 
 func (eh *FaasEventHandler) ReportRequestStart(requestID string) {
-	eh.Tracer.StartReqSpan(requestID)
+	eh.debug(requestID, "request started")
+	if eh.Tracer != nil {
+		eh.Tracer.StartReqSpan(requestID, eh.Header)
+	}
+	if eh.Emitter != nil {
+		eh.Emitter.Emit(RequestStartedEvent, requestID, "", nil)
+	}
+	hooks.Fire(eh.Hooks.OnRequestStart, hooks.Event{RequestID: requestID})
 }
 
 func (eh *FaasEventHandler) ReportRequestFailure(requestID string, err error) {
-	// TODO: add log
-	eh.Tracer.StopReqSpan()
+	eh.debug(requestID, "request failed", hlog.F("error", err))
+	if ownership := FlowOwnership(); ownership != nil {
+		eh.logger().Error("request failed", hlog.F("request", requestID), hlog.F("error", err),
+			hlog.F("owner", ownership.Owner), hlog.F("escalation", ownership.Escalation),
+			hlog.F("runbook", ownership.Runbook))
+	}
+	if eh.Tracer != nil {
+		eh.Tracer.StopReqSpan()
+	}
+	if eh.Emitter != nil {
+		eh.Emitter.Emit(RequestFailedEvent, requestID, "", err)
+	}
+	if eh.OnFailure != nil {
+		eh.OnFailure(err)
+	}
+	hooks.Fire(eh.Hooks.OnFlowFailure, hooks.Event{RequestID: requestID, Duration: eh.since(eh.StartTime), Err: err})
 }
 
 func (eh *FaasEventHandler) ReportExecutionForward(currentNodeID string, requestID string) {
+	eh.debug(requestID, "execution forwarded", hlog.F("node", currentNodeID))
 	eh.CurrentNodeID = currentNodeID
 }
 
 func (eh *FaasEventHandler) ReportExecutionContinuation(requestID string) {
-	eh.Tracer.ContinueReqSpan(requestID, eh.Header)
+	eh.debug(requestID, "execution continued")
+	if eh.Tracer != nil {
+		eh.Tracer.ContinueReqSpan(requestID, eh.Header)
+	}
 }
 
 func (eh *FaasEventHandler) ReportRequestEnd(requestID string) {
-	eh.Tracer.StopReqSpan()
+	eh.debug(requestID, "request completed")
+	if eh.Tracer != nil {
+		eh.Tracer.StopReqSpan()
+	}
+	if eh.Emitter != nil {
+		eh.Emitter.Emit(RequestFinishedEvent, requestID, "", nil)
+	}
+	hooks.Fire(eh.Hooks.OnFlowSuccess, hooks.Event{RequestID: requestID, Duration: eh.since(eh.StartTime)})
 }
 
 func (eh *FaasEventHandler) ReportNodeStart(nodeID string, requestID string) {
-	eh.Tracer.StartNodeSpan(nodeID, requestID)
+	eh.debug(requestID, "node started", hlog.F("node", nodeID))
+	if eh.Tracer != nil {
+		eh.Tracer.StartNodeSpan(nodeID, requestID)
+	}
+	eh.nodeStarts[nodeID] = time.Now()
+	hooks.Fire(eh.Hooks.OnNodeStart, hooks.Event{RequestID: requestID, NodeID: nodeID})
 }
 
 func (eh *FaasEventHandler) ReportNodeEnd(nodeID string, requestID string) {
-	eh.Tracer.StopNodeSpan(nodeID)
+	eh.debug(requestID, "node completed", hlog.F("node", nodeID))
+	if eh.Tracer != nil {
+		eh.Tracer.StopNodeSpan(nodeID)
+	}
+	if eh.Emitter != nil {
+		eh.Emitter.Emit(NodeCompletedEvent, requestID, nodeID, nil)
+	}
+	hooks.Fire(eh.Hooks.OnNodeComplete, hooks.Event{RequestID: requestID, NodeID: nodeID, Duration: eh.since(eh.nodeStarts[nodeID])})
 }
 
 func (eh *FaasEventHandler) ReportNodeFailure(nodeID string, requestID string, err error) {
-	// TODO: add log
-	eh.Tracer.StopNodeSpan(nodeID)
+	eh.debug(requestID, "node failed", hlog.F("node", nodeID), hlog.F("error", err))
+	if eh.Tracer != nil {
+		eh.Tracer.StopNodeSpan(nodeID)
+	}
+	if eh.Emitter != nil {
+		eh.Emitter.Emit(NodeFailedEvent, requestID, nodeID, err)
+	}
+	hooks.Fire(eh.Hooks.OnNodeFailure, hooks.Event{RequestID: requestID, NodeID: nodeID, Duration: eh.since(eh.nodeStarts[nodeID]), Err: err})
 }
 
 func (eh *FaasEventHandler) ReportOperationStart(operationID string, nodeID string, requestID string) {
-	eh.Tracer.StartOperationSpan(nodeID, requestID, operationID)
+	eh.debug(requestID, "operation started", hlog.F("node", nodeID), hlog.F("operation", operationID))
+	if eh.Tracer != nil {
+		eh.Tracer.StartOperationSpan(nodeID, requestID, operationID)
+	}
 }
 
 func (eh *FaasEventHandler) ReportOperationEnd(operationID string, nodeID string, requestID string) {
-	eh.Tracer.StopOperationSpan(nodeID, operationID)
+	eh.debug(requestID, "operation completed", hlog.F("node", nodeID), hlog.F("operation", operationID))
+	if eh.Tracer != nil {
+		eh.Tracer.StopOperationSpan(nodeID, operationID)
+	}
 }
 
 func (eh *FaasEventHandler) ReportOperationFailure(operationID string, nodeID string, requestID string, err error) {
-	// TODO: add log
-	eh.Tracer.StopOperationSpan(nodeID, operationID)
+	eh.debug(requestID, "operation failed", hlog.F("node", nodeID), hlog.F("operation", operationID), hlog.F("error", err))
+	if eh.Tracer != nil {
+		eh.Tracer.StopOperationSpan(nodeID, operationID)
+	}
 }
 
 func (eh *FaasEventHandler) Flush() {
-	eh.Tracer.FlushTracer()
+	if eh.Tracer != nil {
+		eh.Tracer.FlushTracer()
+	}
+}
+
+// since returns the time elapsed since start, zero if start is unknown
+func (eh *FaasEventHandler) since(start time.Time) time.Duration {
+	if start.IsZero() {
+		return 0
+	}
+	return time.Since(start)
+}
+
+// FlowOwnership returns the configured ownership of the flow, nil if not set
+func FlowOwnership() *Ownership {
+	ownership := &Ownership{
+		Owner:      config.FlowOwner(),
+		Escalation: config.FlowEscalation(),
+		Runbook:    config.FlowRunbook(),
+	}
+	if *ownership == (Ownership{}) {
+		return nil
+	}
+	return ownership
+}
+
+// logger returns the logger of the flow
+func (eh *FaasEventHandler) logger() hlog.Logger {
+	logger := eh.Logger
+	if logger == nil {
+		logger = hlog.NewStdLogger(hlog.InfoLevel)
+	}
+	return logger.With(hlog.F("flow", eh.flowName))
+}
+
+// debug logs an event of the request when debug is enabled
+func (eh *FaasEventHandler) debug(requestID string, msg string, fields ...hlog.Field) {
+	if !eh.Debug {
+		return
+	}
+	eh.logger().With(hlog.F("request", requestID)).Info(msg, fields...)
 }
@@ -5,15 +5,17 @@ import (
 	"time"
 
 	hconfig "handler/config"
+	hlog "handler/log"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber/jaeger-client-go"
 	"github.com/uber/jaeger-client-go/config"
+	"github.com/uber/jaeger-client-go/zipkin"
 )
 
 // initRequestTracer init global trace with configuration
-func initRequestTracer(flowName string) (*TraceHandler, error) {
-	tracerObj := &TraceHandler{}
+func initRequestTracer(flowName string, logger hlog.Logger) (*TraceHandler, error) {
+	tracerObj := &TraceHandler{logger: logger}
 
 	agentPort := hconfig.TraceServer()
 
@@ -30,9 +32,26 @@ func initRequestTracer(flowName string) (*TraceHandler, error) {
 		},
 	}
 
-	opentracer, traceCloser, err := cfg.NewTracer(
-		config.Logger(jaeger.StdLogger),
-	)
+	options := []config.Option{config.Logger(jaeger.StdLogger)}
+	switch propagation := hconfig.TracePropagation(); propagation {
+	case "jaeger":
+		tracerObj.propagationHeader = "Uber-Trace-Id"
+	case "b3":
+		b3Propagator := zipkin.NewZipkinB3HTTPHeaderPropagator()
+		options = append(options,
+			config.Injector(opentracing.HTTPHeaders, b3Propagator),
+			config.Extractor(opentracing.HTTPHeaders, b3Propagator))
+		tracerObj.propagationHeader = "X-B3-Traceid"
+	case "w3c":
+		options = append(options,
+			config.Injector(opentracing.HTTPHeaders, w3cPropagator{}),
+			config.Extractor(opentracing.HTTPHeaders, w3cPropagator{}))
+		tracerObj.propagationHeader = traceParentHeader
+	default:
+		return nil, fmt.Errorf("unknown trace propagation %s", propagation)
+	}
+
+	opentracer, traceCloser, err := cfg.NewTracer(options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init Tracer, error %v", err.Error())
 	}
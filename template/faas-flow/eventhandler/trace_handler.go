@@ -1,31 +1,47 @@
 package eventhandler
 
 import (
-	"fmt"
-
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 
 	"io"
 	"net/http"
+
+	hlog "handler/log"
 )
 
 type TraceHandler struct {
 	tracer opentracing.Tracer
 	closer io.Closer
+	// propagationHeader is the header set when a span context is injected
+	propagationHeader string
+	logger            hlog.Logger
 
 	reqSpan    opentracing.Span
 	reqSpanCtx opentracing.SpanContext
+	// resumed is set when the request span context was continued from a
+	// previous execution of the request
+	resumed bool
 
 	nodeSpans      map[string]opentracing.Span
 	operationSpans map[string]map[string]opentracing.Span
 }
 
-// StartReqSpan starts a request span
-func (tracerObj *TraceHandler) StartReqSpan(reqID string) {
-	tracerObj.reqSpan = tracerObj.tracer.StartSpan(reqID)
+// StartReqSpan starts a request span, as a child of the trigger span if
+// its context is present in the header
+func (tracerObj *TraceHandler) StartReqSpan(reqID string, header http.Header) {
+	triggerSpanCtx, err := tracerObj.tracer.Extract(
+		opentracing.HTTPHeaders,
+		opentracing.HTTPHeadersCarrier(header),
+	)
+	if err == nil {
+		tracerObj.reqSpan = tracerObj.tracer.StartSpan(reqID, opentracing.ChildOf(triggerSpanCtx))
+	} else {
+		tracerObj.reqSpan = tracerObj.tracer.StartSpan(reqID)
+	}
 	tracerObj.reqSpan.SetTag("request", reqID)
 	tracerObj.reqSpanCtx = tracerObj.reqSpan.Context()
+	tracerObj.resumed = false
 }
 
 // ContinueReqSpan continue request span
@@ -37,11 +53,12 @@ func (tracerObj *TraceHandler) ContinueReqSpan(reqID string, header http.Header)
 		opentracing.HTTPHeadersCarrier(header),
 	)
 	if err != nil {
-		fmt.Printf("[Request %s] failed to continue req span for tracing, error %v\n", reqID, err)
+		tracerObj.logger.Warn("failed to continue req span for tracing", hlog.F("request", reqID), hlog.F("error", err))
 		return
 	}
 
 	tracerObj.reqSpan = nil
+	tracerObj.resumed = true
 	// TODO: Its not Supported to get span from spanContext as of now
 	//       https://github.com/opentracing/specification/issues/81
 	//       it will support us to extend the request span for nodes
@@ -50,7 +67,7 @@ func (tracerObj *TraceHandler) ContinueReqSpan(reqID string, header http.Header)
 
 // ExtendReqSpan extend req span over a request
 // func ExtendReqSpan(url string, req *http.Request) {
-func (tracerObj *TraceHandler) ExtendReqSpan(reqID string, lastNode string, url string, req *http.Request) {
+func (tracerObj *TraceHandler) ExtendReqSpan(reqID string, lastNode string, url string, header http.Header) {
 	// TODO: as requestSpan can't be regenerated with the span context we
 	//       forward the nodes SpanContext
 	// span := reqSpan
@@ -65,14 +82,14 @@ func (tracerObj *TraceHandler) ExtendReqSpan(reqID string, lastNode string, url
 	err := span.Tracer().Inject(
 		span.Context(),
 		opentracing.HTTPHeaders,
-		opentracing.HTTPHeadersCarrier(req.Header),
+		opentracing.HTTPHeadersCarrier(header),
 	)
 	if err != nil {
-		fmt.Printf("[Request %s] failed to extend req span for tracing, error %v\n", reqID, err)
+		tracerObj.logger.Warn("failed to extend req span for tracing", hlog.F("request", reqID), hlog.F("error", err))
 	}
-	if req.Header.Get("Uber-Trace-Id") == "" {
-		fmt.Printf("[Request %s] failed to extend req span for tracing, error Uber-Trace-Id not set\n",
-			reqID)
+	if header.Get(tracerObj.propagationHeader) == "" {
+		tracerObj.logger.Warn("failed to extend req span for tracing, propagation header not set",
+			hlog.F("request", reqID), hlog.F("header", tracerObj.propagationHeader))
 	}
 }
 
@@ -88,8 +105,17 @@ func (tracerObj *TraceHandler) StopReqSpan() {
 // StartNodeSpan starts a node span
 func (tracerObj *TraceHandler) StartNodeSpan(node string, reqID string) {
 
-	tracerObj.nodeSpans[node] = tracerObj.tracer.StartSpan(
-		node, ext.RPCServerOption(tracerObj.reqSpanCtx))
+	if tracerObj.resumed {
+		// reqSpanCtx is the context of the node span which forwarded the
+		// request (the request span can't be recovered, see ContinueReqSpan),
+		// the node follows from it in the same trace and is tagged resumed
+		tracerObj.nodeSpans[node] = tracerObj.tracer.StartSpan(
+			node, opentracing.FollowsFrom(tracerObj.reqSpanCtx), ext.SpanKindRPCServer)
+		tracerObj.nodeSpans[node].SetTag("resumed", "true")
+	} else {
+		tracerObj.nodeSpans[node] = tracerObj.tracer.StartSpan(
+			node, ext.RPCServerOption(tracerObj.reqSpanCtx))
+	}
 
 	/*
 		 tracerObj.nodeSpans[node] = tracerObj.Tracer.StartSpan(
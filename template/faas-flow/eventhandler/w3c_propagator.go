@@ -0,0 +1,69 @@
+package eventhandler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+)
+
+const traceParentHeader = "traceparent"
+
+// implements jaeger.Injector and jaeger.Extractor
+// w3cPropagator propagates the span context with the W3C traceparent header
+type w3cPropagator struct{}
+
+func (p w3cPropagator) Inject(sc jaeger.SpanContext, carrier interface{}) error {
+	writer, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+
+	flags := 0
+	if sc.IsSampled() {
+		flags = 1
+	}
+	writer.Set(traceParentHeader, fmt.Sprintf("00-%016x%016x-%016x-%02x",
+		sc.TraceID().High, sc.TraceID().Low, uint64(sc.SpanID()), flags))
+	return nil
+}
+
+func (p w3cPropagator) Extract(carrier interface{}) (jaeger.SpanContext, error) {
+	reader, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return jaeger.SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+
+	traceParent := ""
+	err := reader.ForeachKey(func(key, value string) error {
+		if strings.ToLower(key) == traceParentHeader {
+			traceParent = value
+		}
+		return nil
+	})
+	if err != nil {
+		return jaeger.SpanContext{}, err
+	}
+	if traceParent == "" {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextNotFound
+	}
+
+	// version-traceid-parentid-flags
+	parts := strings.Split(traceParent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	high, highErr := strconv.ParseUint(parts[1][:16], 16, 64)
+	low, lowErr := strconv.ParseUint(parts[1][16:], 16, 64)
+	spanID, spanErr := strconv.ParseUint(parts[2], 16, 64)
+	flags, flagsErr := strconv.ParseUint(parts[3], 16, 8)
+	if highErr != nil || lowErr != nil || spanErr != nil || flagsErr != nil {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	traceID := jaeger.TraceID{High: high, Low: low}
+	sampled := flags&1 == 1
+	return jaeger.NewSpanContext(traceID, jaeger.SpanID(spanID), 0, sampled, nil), nil
+}
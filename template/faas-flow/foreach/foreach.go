@@ -0,0 +1,142 @@
+package foreach
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	hlog "handler/log"
+)
+
+// logger logs the errors of the package, it is set by the runtime
+var logger hlog.Logger = hlog.NewStdLogger(hlog.InfoLevel)
+
+// SetLogger sets the logger of the package, it must be called before the
+// flow is executed
+func SetLogger(l hlog.Logger) {
+	logger = l
+}
+
+// SplitJSONArray returns a foreach function that splits the JSON array at
+// path (dot separated, empty for the root) in batches of batchSize
+// elements, each batch is a branch keyed by its index.
+// It panics if batchSize is not positive
+func SplitJSONArray(path string, batchSize int) func(data []byte) map[string][]byte {
+	mustBePositive("SplitJSONArray", "batchSize", batchSize)
+	return func(data []byte) map[string][]byte {
+		elements, err := jsonArray(data, path)
+		if err != nil {
+			logger.Error("failed to split json array", hlog.F("path", path), hlog.F("error", err))
+			return nil
+		}
+
+		branches := make(map[string][]byte)
+		for start := 0; start < len(elements); start += batchSize {
+			end := start + batchSize
+			if end > len(elements) {
+				end = len(elements)
+			}
+			batch, _ := json.Marshal(elements[start:end])
+			branches[strconv.Itoa(start/batchSize)] = batch
+		}
+		return branches
+	}
+}
+
+// PartitionByKeyHash returns a foreach function that partitions the
+// elements of the JSON array at path (dot separated, empty for the root) in
+// shards by the hash of the value at keyPath in each element, each non
+// empty shard is a branch keyed shard-<n>.
+// It panics if shards is not positive
+func PartitionByKeyHash(path string, keyPath string, shards int) func(data []byte) map[string][]byte {
+	mustBePositive("PartitionByKeyHash", "shards", shards)
+	return func(data []byte) map[string][]byte {
+		elements, err := jsonArray(data, path)
+		if err != nil {
+			logger.Error("failed to partition json array", hlog.F("path", path), hlog.F("error", err))
+			return nil
+		}
+
+		partitions := make(map[string][]json.RawMessage)
+		for _, element := range elements {
+			key, err := jsonValue(element, keyPath)
+			if err != nil {
+				logger.Error("failed to partition json array", hlog.F("path", path), hlog.F("error", err))
+				return nil
+			}
+			hash := fnv.New32a()
+			hash.Write(key)
+			shard := fmt.Sprintf("shard-%d", hash.Sum32()%uint32(shards))
+			partitions[shard] = append(partitions[shard], element)
+		}
+
+		branches := make(map[string][]byte)
+		for shard, partition := range partitions {
+			branches[shard], _ = json.Marshal(partition)
+		}
+		return branches
+	}
+}
+
+// ChunkBytes returns a foreach function that splits the data in chunks of
+// size bytes, each chunk is a branch keyed by its index.
+// It panics if size is not positive
+func ChunkBytes(size int) func(data []byte) map[string][]byte {
+	mustBePositive("ChunkBytes", "size", size)
+	return func(data []byte) map[string][]byte {
+		branches := make(map[string][]byte)
+		for start := 0; start < len(data); start += size {
+			end := start + size
+			if end > len(data) {
+				end = len(data)
+			}
+			branches[strconv.Itoa(start/size)] = data[start:end]
+		}
+		return branches
+	}
+}
+
+// mustBePositive panics if value is not positive, the helpers are built in
+// Define() so an invalid size fails the flow definition instead of the foreach
+func mustBePositive(helper string, name string, value int) {
+	if value <= 0 {
+		panic(fmt.Sprintf("foreach.%s: %s must be positive, got %d", helper, name, value))
+	}
+}
+
+// jsonArray returns the elements of the JSON array at path in data
+func jsonArray(data []byte, path string) ([]json.RawMessage, error) {
+	value, err := jsonValue(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(value, &elements); err != nil {
+		return nil, fmt.Errorf("value at %q is not an array, error %v", path, err)
+	}
+	return elements, nil
+}
+
+// jsonValue returns the raw JSON value at path (dot separated) in data
+func jsonValue(data []byte, path string) (json.RawMessage, error) {
+	value := json.RawMessage(data)
+	if path == "" {
+		return value, nil
+	}
+
+	for _, field := range strings.Split(path, ".") {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(value, &object); err != nil {
+			return nil, fmt.Errorf("failed to read %q, not an object at %s", path, field)
+		}
+		var ok bool
+		value, ok = object[field]
+		if !ok {
+			return nil, fmt.Errorf("failed to read %q, %s not found", path, field)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,101 @@
+package foreach
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSplitJSONArray(t *testing.T) {
+	split := SplitJSONArray("order.items", 2)
+	branches := split([]byte(`{"order":{"items":[1,2,3,4,5]}}`))
+
+	expected := map[string]string{"0": "[1,2]", "1": "[3,4]", "2": "[5]"}
+	if len(branches) != len(expected) {
+		t.Fatalf("expected %d branches, got %d", len(expected), len(branches))
+	}
+	for key, value := range expected {
+		if string(branches[key]) != value {
+			t.Errorf("branch %s: expected %s, got %s", key, value, branches[key])
+		}
+	}
+}
+
+func TestSplitJSONArrayNotAnArray(t *testing.T) {
+	split := SplitJSONArray("items", 2)
+	if branches := split([]byte(`{"items":{}}`)); branches != nil {
+		t.Errorf("expected no branch, got %v", branches)
+	}
+}
+
+func TestPartitionByKeyHash(t *testing.T) {
+	partition := PartitionByKeyHash("orders", "customer.id", 4)
+	branches := partition([]byte(`{"orders":[
+		{"customer":{"id":"a"},"n":1},
+		{"customer":{"id":"b"},"n":2},
+		{"customer":{"id":"a"},"n":3},
+		{"customer":{"id":"c"},"n":4}]}`))
+
+	count := 0
+	shardOf := make(map[string]string)
+	for shard, data := range branches {
+		var elements []struct {
+			Customer struct {
+				ID string `json:"id"`
+			} `json:"customer"`
+		}
+		if err := json.Unmarshal(data, &elements); err != nil {
+			t.Fatalf("shard %s is not an array, error %v", shard, err)
+		}
+		for _, element := range elements {
+			if other, ok := shardOf[element.Customer.ID]; ok && other != shard {
+				t.Errorf("key %s is in shard %s and %s", element.Customer.ID, other, shard)
+			}
+			shardOf[element.Customer.ID] = shard
+			count++
+		}
+	}
+	if count != 4 {
+		t.Errorf("expected 4 elements, got %d", count)
+	}
+}
+
+func TestPartitionByKeyHashMissingKey(t *testing.T) {
+	partition := PartitionByKeyHash("", "id", 2)
+	if branches := partition([]byte(`[{"id":1},{"name":"x"}]`)); branches != nil {
+		t.Errorf("expected no branch, got %v", branches)
+	}
+}
+
+func TestChunkBytes(t *testing.T) {
+	branches := ChunkBytes(3)([]byte("abcdefg"))
+
+	expected := map[string]string{"0": "abc", "1": "def", "2": "g"}
+	if len(branches) != len(expected) {
+		t.Fatalf("expected %d branches, got %d", len(expected), len(branches))
+	}
+	for key, value := range expected {
+		if string(branches[key]) != value {
+			t.Errorf("branch %s: expected %s, got %s", key, value, branches[key])
+		}
+	}
+}
+
+func TestInvalidSizesPanic(t *testing.T) {
+	builders := map[string]func(){
+		"SplitJSONArray zero":     func() { SplitJSONArray("", 0) },
+		"SplitJSONArray negative": func() { SplitJSONArray("", -1) },
+		"PartitionByKeyHash zero": func() { PartitionByKeyHash("", "id", 0) },
+		"ChunkBytes zero":         func() { ChunkBytes(0) },
+		"ChunkBytes negative":     func() { ChunkBytes(-3) },
+	}
+	for name, build := range builders {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			build()
+		}()
+	}
+}
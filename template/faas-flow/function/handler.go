@@ -3,6 +3,9 @@ package function
 import (
 	"fmt"
 	faasflow "github.com/faasflow/lib/openfaas"
+	"handler/hooks"
+	hlog "handler/log"
+	"handler/queue"
 )
 
 // Define provide definition of the workflow
@@ -26,3 +29,31 @@ func OverrideDataStore() (faasflow.DataStore, error) {
 	//       This can be overridden with other synchronous KV store
 	return nil, nil
 }
+
+// OverrideQueueProvider provides the override of the default QueueProvider
+func OverrideQueueProvider() (queue.QueueProvider, error) {
+	// NOTE: By default FaaS-Flow forward async calls with the OpenFaaS gateway,
+	//       This can be overridden with other queue (e.g. SQS)
+	return nil, nil
+}
+
+// OverrideForwardInterceptor provides an interceptor of the async calls
+func OverrideForwardInterceptor() (queue.ForwardInterceptor, error) {
+	// NOTE: By default FaaS-Flow forward partial states as it is,
+	//       This can be used to veto, delay or transform them (e.g. encrypt)
+	return nil, nil
+}
+
+// OverrideLogger provides the override of the default Logger
+func OverrideLogger() (hlog.Logger, error) {
+	// NOTE: By default FaaS-Flow logs with the standard library in logfmt,
+	//       This can be overridden with other logger (e.g. zap)
+	return nil, nil
+}
+
+// OverrideHooks provides the lifecycle hooks of the flow
+func OverrideHooks() (*hooks.Hooks, error) {
+	// NOTE: By default FaaS-Flow doesn't register any hook,
+	//       This can be used to emit business events (e.g. on node completion)
+	return nil, nil
+}
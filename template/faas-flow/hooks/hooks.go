@@ -0,0 +1,54 @@
+package hooks
+
+import (
+	"time"
+)
+
+// Event is passed to the lifecycle hooks
+type Event struct {
+	RequestID   string
+	NodeID      string        // unique ID of the node, empty for request events
+	Time        time.Time     // time of the event
+	Duration    time.Duration // time since the node (or request) started, zero when unknown
+	Key         string        // key of the data persisted, for OnStatePersist
+	PayloadSize int           // size of the forwarded state or persisted data, zero when unknown
+	Err         error         // error of failure events
+}
+
+// Hooks are the callbacks invoked on the lifecycle events of a request,
+// a nil hook is skipped
+type Hooks struct {
+	OnRequestStart func(Event)
+	OnNodeStart    func(Event)
+	OnNodeComplete func(Event)
+	OnNodeFailure  func(Event)
+	OnForward      func(Event) // partial state forwarded to the next node
+	OnStatePersist func(Event) // data persisted in the DataStore (e.g. context.Set)
+	OnFlowSuccess  func(Event)
+	OnFlowFailure  func(Event)
+}
+
+// Any returns true if at least one hook is registered
+func (h *Hooks) Any() bool {
+	if h == nil {
+		return false
+	}
+	return h.OnRequestStart != nil || h.OnNodeStart != nil || h.OnNodeComplete != nil ||
+		h.OnNodeFailure != nil || h.OnForward != nil || h.OnStatePersist != nil || h.OnFlowSuccess != nil ||
+		h.OnFlowFailure != nil
+}
+
+// Fire invokes the hook when set, a panic in the hook is recovered so that
+// it never fails the request
+func Fire(hook func(Event), event Event) {
+	if hook == nil {
+		return
+	}
+	defer func() {
+		recover()
+	}()
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	hook(event)
+}
@@ -0,0 +1,23 @@
+package log
+
+// Field is a key/value pair attached to a log entry
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F creates a Field
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger is a leveled structured logger used by the executor, it can be
+// overridden to log with zap, zerolog, etc.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+	// With returns a Logger which adds fields to every entry
+	With(fields ...Field) Logger
+}
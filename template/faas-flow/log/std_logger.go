@@ -0,0 +1,83 @@
+package log
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Level of a log entry
+type Level int
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = map[Level]string{
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+}
+
+// ParseLevel parses a level name, it defaults to InfoLevel
+func ParseLevel(name string) Level {
+	for level, levelName := range levelNames {
+		if strings.ToLower(name) == levelName {
+			return level
+		}
+	}
+	return InfoLevel
+}
+
+// implements Logger
+// StdLogger logs entries of at least Level with the standard library
+// logger in logfmt (level=info msg="..." key=value)
+type StdLogger struct {
+	Level  Level
+	fields []Field
+}
+
+// NewStdLogger creates a StdLogger logging entries of at least level
+func NewStdLogger(level Level) *StdLogger {
+	return &StdLogger{Level: level}
+}
+
+func (l *StdLogger) Debug(msg string, fields ...Field) {
+	l.log(DebugLevel, msg, fields)
+}
+
+func (l *StdLogger) Info(msg string, fields ...Field) {
+	l.log(InfoLevel, msg, fields)
+}
+
+func (l *StdLogger) Warn(msg string, fields ...Field) {
+	l.log(WarnLevel, msg, fields)
+}
+
+func (l *StdLogger) Error(msg string, fields ...Field) {
+	l.log(ErrorLevel, msg, fields)
+}
+
+func (l *StdLogger) With(fields ...Field) Logger {
+	withFields := make([]Field, 0, len(l.fields)+len(fields))
+	withFields = append(withFields, l.fields...)
+	withFields = append(withFields, fields...)
+	return &StdLogger{Level: l.Level, fields: withFields}
+}
+
+func (l *StdLogger) log(level Level, msg string, fields []Field) {
+	if level < l.Level {
+		return
+	}
+
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "level=%s msg=%q", levelNames[level], msg)
+	for _, field := range append(l.fields, fields...) {
+		fmt.Fprintf(&entry, " %s=%q", field.Key, fmt.Sprint(field.Value))
+	}
+	log.Print(entry.String())
+}
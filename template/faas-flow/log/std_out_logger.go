@@ -2,16 +2,30 @@ package log
 
 import (
 	"fmt"
+	"strings"
 )
 
 // implements faasflow.Logger
-type StdOutLogger struct{}
+// StdOutLogger prints the sdk logs, through Logger with the flow and
+// request fields when set
+type StdOutLogger struct {
+	Logger        Logger
+	requestLogger Logger
+}
 
-func (l *StdOutLogger) Configure(flowName string, requestId string) {}
+func (l *StdOutLogger) Configure(flowName string, requestId string) {
+	if l.Logger != nil {
+		l.requestLogger = l.Logger.With(F("flow", flowName), F("request", requestId))
+	}
+}
 
 func (l *StdOutLogger) Init() error {
 	return nil
 }
 func (l *StdOutLogger) Log(str string) {
-	fmt.Print(str)
+	if l.requestLogger == nil {
+		fmt.Print(str)
+		return
+	}
+	l.requestLogger.Info(strings.TrimRight(str, "\n"))
 }
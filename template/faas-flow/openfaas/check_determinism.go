@@ -0,0 +1,58 @@
+package openfaas
+
+import (
+	"fmt"
+	"sync"
+
+	faasflow "github.com/faasflow/lib/openfaas"
+	sdk "github.com/faasflow/sdk"
+	"handler/function"
+	hlog "handler/log"
+	"handler/store"
+)
+
+// determinismCheck holds the result of the check, done once per replica
+// unless the second definition fails
+var determinismCheck struct {
+	mutex sync.Mutex
+	done  bool
+	err   error
+}
+
+// checkDeterminism defines the flow a second time and compares the DAG with
+// the one of pipeline, as async calls rebuild the DAG on every execution a
+// definition depending on time or random values breaks the resumed requests.
+// Both definitions are built in the same process, differences between the
+// replicas (e.g. env) are not detected. The check runs at the first request
+// of each replica and fails the requests, not the deployment
+func checkDeterminism(logger hlog.Logger, flowName string, pipeline *sdk.Pipeline, context *sdk.Context) error {
+	determinismCheck.mutex.Lock()
+	defer determinismCheck.mutex.Unlock()
+
+	if determinismCheck.done {
+		return determinismCheck.err
+	}
+
+	// the second definition gets a throwaway context so that its side
+	// effects on the context (e.g. context.Set) don't reach the request data
+	otherContext := sdk.CreateContext(context.GetRequestId(), context.GetNode(), flowName, store.NewMemoryDataStore())
+	otherContext.Query = context.Query
+	otherContext.State = context.State
+
+	other := sdk.CreatePipeline()
+	err := function.Define(faasflow.GetWorkflow(other), (*faasflow.Context)(otherContext))
+	if err != nil {
+		// the check is retried by the next request
+		return fmt.Errorf("failed to check flow definition, error %v", err)
+	}
+
+	definition := pipeline.GetDagDefinition()
+	otherDefinition := other.GetDagDefinition()
+	if definition != otherDefinition {
+		logger.Error("flow definition is not deterministic",
+			hlog.F("definition", definition), hlog.F("other_definition", otherDefinition))
+		determinismCheck.err = fmt.Errorf("flow definition is not deterministic, Define() built two different DAGs")
+	}
+	determinismCheck.done = true
+	return determinismCheck.err
+}
@@ -0,0 +1,30 @@
+package openfaas
+
+import (
+	"time"
+
+	"github.com/faasflow/sdk"
+	"handler/hooks"
+)
+
+// implements faasflow.DataStore
+// hookedDataStore invokes the OnStatePersist hook when data is persisted,
+// it is created per executor so that it holds the request ID
+type hookedDataStore struct {
+	sdk.DataStore
+	hooks     *hooks.Hooks
+	requestID string
+	startTime time.Time
+}
+
+func (ds *hookedDataStore) Configure(flowName string, requestID string) {
+	ds.requestID = requestID
+	ds.DataStore.Configure(flowName, requestID)
+}
+
+func (ds *hookedDataStore) Set(key string, value []byte) error {
+	err := ds.DataStore.Set(key, value)
+	hooks.Fire(ds.hooks.OnStatePersist, hooks.Event{RequestID: ds.requestID, Key: key,
+		Duration: time.Since(ds.startTime), PayloadSize: len(value), Err: err})
+	return err
+}
@@ -1,15 +1,19 @@
 package openfaas
 
 import (
-	"log"
+	"encoding/hex"
+	"fmt"
 
+	"handler/config"
 	"handler/function"
+	hlog "handler/log"
+	"handler/store"
 
 	minioDataStore "github.com/faasflow/faas-flow-minio-datastore"
 	"github.com/faasflow/sdk"
 )
 
-func initDataStore() (dataStore sdk.DataStore, err error) {
+func initDataStore(logger hlog.Logger) (dataStore sdk.DataStore, err error) {
 	dataStore, err = function.OverrideDataStore()
 	if err != nil {
 		return nil, err
@@ -46,7 +50,49 @@ func initDataStore() (dataStore sdk.DataStore, err error) {
 		*/
 		dataStore, err = minioDataStore.InitFromEnv()
 
-		log.Print("Using default data store (minio)")
+		logger.Info("using default data store (minio)")
 	}
-	return dataStore, err
+	if err != nil {
+		return nil, err
+	}
+
+	// chunk next to the store so that the encoded values are chunked
+	if size := config.DataChunkSize(); size > 0 {
+		logger.Info("chunking data store values", hlog.F("chunk_size", size))
+		dataStore = store.NewChunkedDataStore(dataStore, size)
+	}
+
+	if config.EncryptData() {
+		dataStore, err = encryptDataStore(logger, dataStore)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// compress before encryption as encrypted data doesn't compress
+	if threshold := config.CompressDataThreshold(); threshold >= 0 {
+		logger.Info("compressing data store values", hlog.F("threshold", threshold))
+		dataStore = store.NewCompressedDataStore(dataStore, threshold)
+	}
+	return dataStore, nil
+}
+
+// encryptDataStore wraps the dataStore with encryption, the master key is
+// read as hex from the secret faasflow-data-key
+func encryptDataStore(logger hlog.Logger, dataStore sdk.DataStore) (sdk.DataStore, error) {
+	secret, err := ReadSecret("faasflow-data-key")
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := hex.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid faasflow-data-key, error %v", err)
+	}
+	kms, err := store.NewLocalKMS(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("encrypting data store")
+	return store.NewEncryptedDataStore(dataStore, kms), nil
 }
@@ -0,0 +1,18 @@
+package openfaas
+
+import (
+	"handler/function"
+	"handler/hooks"
+)
+
+func initHooks() (*hooks.Hooks, error) {
+	flowHooks, err := function.OverrideHooks()
+	if err != nil {
+		return nil, err
+	}
+
+	if flowHooks == nil {
+		flowHooks = &hooks.Hooks{}
+	}
+	return flowHooks, nil
+}
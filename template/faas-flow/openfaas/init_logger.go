@@ -0,0 +1,19 @@
+package openfaas
+
+import (
+	"handler/config"
+	"handler/function"
+	hlog "handler/log"
+)
+
+func initLogger() (logger hlog.Logger, err error) {
+	logger, err = function.OverrideLogger()
+	if err != nil {
+		return nil, err
+	}
+
+	if logger == nil {
+		logger = hlog.NewStdLogger(hlog.ParseLevel(config.LogLevel()))
+	}
+	return logger, nil
+}
@@ -0,0 +1,25 @@
+package openfaas
+
+import (
+	"handler/function"
+	hlog "handler/log"
+	"handler/queue"
+)
+
+func initQueueProvider(logger hlog.Logger) (queueProvider queue.QueueProvider, err error) {
+	queueProvider, err = function.OverrideQueueProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	if queueProvider == nil {
+		// the default queue is created per request from the flow async URL
+		logger.Info("using default queue provider (gateway)")
+	}
+
+	return queueProvider, nil
+}
+
+func initForwardInterceptor() (queue.ForwardInterceptor, error) {
+	return function.OverrideForwardInterceptor()
+}
@@ -1,23 +1,22 @@
 package openfaas
 
 import (
-	"log"
-
 	"handler/config"
 	"handler/function"
+	hlog "handler/log"
 
 	consulStateStore "github.com/faasflow/faas-flow-consul-statestore"
 	"github.com/faasflow/sdk"
 )
 
-func initStateStore() (stateStore sdk.StateStore, err error) {
+func initStateStore(logger hlog.Logger) (stateStore sdk.StateStore, err error) {
 	stateStore, err = function.OverrideStateStore()
 	if err != nil {
 		return nil, err
 	}
 
 	if stateStore == nil {
-		log.Print("Using default state store (consul)")
+		logger.Info("using default state store (consul)")
 
 		consulURL := config.ConsulURL()
 		consulDC := config.ConsulDC()
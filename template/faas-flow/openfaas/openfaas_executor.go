@@ -6,12 +6,12 @@ import (
 	"github.com/faasflow/runtime"
 	"github.com/faasflow/runtime/controller/util"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	faasflow "github.com/faasflow/lib/openfaas"
 	sdk "github.com/faasflow/sdk"
@@ -19,66 +19,96 @@ import (
 	"handler/config"
 	"handler/eventhandler"
 	"handler/function"
+	"handler/hooks"
 	hlog "handler/log"
+	"handler/queue"
 )
 
 // A signature of SHA265 equivalent of github.com/s8sg/faas-flow
 const defaultHmacKey = "71F1D3011F8E6160813B4997BA29856744375A7F26D427D491E1CCABD4627E7C"
 
+const (
+	// deadlineHeader carries the request deadline (RFC3339) across async calls
+	deadlineHeader = "X-Faas-Flow-Deadline"
+	// startTimeHeader carries the request start time (RFC3339) across async calls
+	startTimeHeader = "X-Faas-Flow-Start-Time"
+	// debugHeader enables debug mode for a request when set to true
+	debugHeader = "X-Faas-Flow-Debug"
+)
+
 // implements faasflow.Executor + RequestHandler
 type OpenFaasExecutor struct {
-	gateway      string
-	asyncURL     string // the async URL of the flow
-	flowName     string // the name of the function
-	reqID        string // the request id
-	CallbackURL  string // the callback url
-	partialState []byte
-	rawRequest   *executor.RawRequest
-	StateStore   sdk.StateStore
-	DataStore    sdk.DataStore
-	EventHandler sdk.EventHandler
-	logger       hlog.StdOutLogger
+	gateway            string
+	asyncURL           string    // the async URL of the flow
+	flowName           string    // the name of the function
+	reqID              string    // the request id
+	CallbackURL        string    // the callback url
+	Deadline           time.Time // the request deadline, zero if not set
+	StartTime          time.Time // the time the request was received first
+	Debug              bool      // debug mode of the request
+	partialState       []byte
+	rawRequest         *executor.RawRequest
+	StateStore         sdk.StateStore
+	DataStore          sdk.DataStore
+	EventHandler       sdk.EventHandler
+	QueueProvider      queue.QueueProvider      // forwards partial states to the next execution
+	ForwardInterceptor queue.ForwardInterceptor // intercepts the partial states before they are forwarded
+	ForwardPacer       *queue.Pacer             // paces the async calls of all requests
+	Logger             hlog.Logger              // structured logger of the executor
+	requestLogger      hlog.Logger
+	logger             hlog.StdOutLogger
 }
 
 func (of *OpenFaasExecutor) HandleNextNode(partial *executor.PartialState) error {
 
+	// the deadline is checked here, as an error returned to the sdk fails the
+	// request through its failure path (cleanup, failure handlers and events)
+	if !of.Deadline.IsZero() && time.Now().After(of.Deadline) {
+		return fmt.Errorf("request deadline exceeded at %s", of.Deadline.Format(time.RFC3339))
+	}
+
 	state, err := partial.Encode()
 	if err != nil {
 		return fmt.Errorf("failed to encode partial state, error %v", err)
 	}
 
-	url, _ := url.Parse(of.asyncURL)
-	url.Path = path.Join(url.Path, "flow", of.reqID, "forward")
+	// the node forwarded from is only known when monitoring is enabled, as the
+	// sdk reports it to the event handler
+	faasHandler := of.EventHandler.(*eventhandler.FaasEventHandler)
+	forwardLog := of.log()
+	if faasHandler.CurrentNodeID != "" {
+		forwardLog = forwardLog.With(hlog.F("node", faasHandler.CurrentNodeID))
+	}
 
-	// build url for calling the flow in async
-	httpReq, _ := http.NewRequest(http.MethodPost, url.String(), bytes.NewReader(state))
-	httpReq.Header.Add("Accept", "application/json")
-	httpReq.Header.Add("Content-Type", "application/json")
-	httpReq.Header.Add(util.RequestIdHeader, of.reqID)
-	httpReq.Header.Set(util.CallbackUrlHeader, of.CallbackURL)
+	header := http.Header{}
+	header.Add("Accept", "application/json")
+	header.Add("Content-Type", "application/json")
+	header.Add(util.RequestIdHeader, of.reqID)
+	header.Set(util.CallbackUrlHeader, of.CallbackURL)
+	header.Set(startTimeHeader, of.StartTime.Format(time.RFC3339Nano))
+	of.stampRequest(header)
+	if of.Debug {
+		header.Set(debugHeader, "true")
+		forwardLog.Info("forwarding partial state", hlog.F("state", string(state)))
+	}
+	if !of.Deadline.IsZero() {
+		header.Set(deadlineHeader, of.Deadline.Format(time.RFC3339))
+	}
 
-	fmt.Println(fmt.Sprint(of.asyncURL, of.reqID))
-	fmt.Println(httpReq)
+	forwardURL, _ := queue.ForwardURL(of.asyncURL, of.reqID)
+	forwardLog.Info("forwarding request", hlog.F("url", forwardURL))
 
 	// extend req span for async call
-	if of.MonitoringEnabled() {
-		faasHandler := of.EventHandler.(*eventhandler.FaasEventHandler)
-		faasHandler.Tracer.ExtendReqSpan(of.reqID, faasHandler.CurrentNodeID, url.String(), httpReq)
+	if of.MonitoringEnabled() && faasHandler.Tracer != nil {
+		faasHandler.Tracer.ExtendReqSpan(of.reqID, faasHandler.CurrentNodeID, forwardURL, header)
 	}
 
-	client := &http.Client{}
-	res, resErr := client.Do(httpReq)
-	if resErr != nil {
-		return resErr
+	if faasHandler.Hooks != nil {
+		hooks.Fire(faasHandler.Hooks.OnForward, hooks.Event{RequestID: of.reqID, NodeID: faasHandler.CurrentNodeID,
+			Duration: time.Since(of.StartTime), PayloadSize: len(state)})
 	}
 
-	defer res.Body.Close()
-	resData, _ := ioutil.ReadAll(res.Body)
-
-	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("%d: %s", res.StatusCode, string(resData))
-	}
-	return nil
+	return of.QueueProvider.Enqueue(of.reqID, state, header)
 }
 
 func (of *OpenFaasExecutor) GetExecutionOption(operation sdk.Operation) map[string]interface{} {
@@ -94,9 +124,34 @@ func (of *OpenFaasExecutor) HandleExecutionCompletion(data []byte) error {
 		return nil
 	}
 
-	log.Printf("calling callback url (%s) with result", of.CallbackURL)
+	of.log().Info("calling callback url with result", hlog.F("url", of.CallbackURL))
+	if of.Debug {
+		of.log().Info("request result", hlog.F("result", string(data)))
+	}
+	return of.callback("completed", data)
+}
+
+// handleExecutionFailure calls the callback url with the error of the request,
+// it is invoked by the event handler when the request fails
+func (of *OpenFaasExecutor) handleExecutionFailure(err error) {
+	if of.CallbackURL == "" {
+		return
+	}
+
+	of.log().Info("calling callback url with failure", hlog.F("url", of.CallbackURL))
+	if cbErr := of.callback("failed", []byte(err.Error())); cbErr != nil {
+		of.log().Error("failed to call callback url", hlog.F("error", cbErr))
+	}
+}
+
+// callback posts the final result (or error) of the request to the callback url
+func (of *OpenFaasExecutor) callback(status string, data []byte) error {
 	httpreq, _ := http.NewRequest(http.MethodPost, of.CallbackURL, bytes.NewReader(data))
 	httpreq.Header.Add("X-Faas-Flow-ReqiD", of.reqID)
+	httpreq.Header.Add("X-Faas-Flow-Status", status)
+	httpreq.Header.Add(startTimeHeader, of.StartTime.Format(time.RFC3339Nano))
+	httpreq.Header.Add("X-Faas-Flow-Duration", time.Since(of.StartTime).String())
+	of.stampRequest(httpreq.Header)
 	client := &http.Client{}
 
 	res, resErr := client.Do(httpreq)
@@ -115,6 +170,16 @@ func (of *OpenFaasExecutor) HandleExecutionCompletion(data []byte) error {
 
 func (of *OpenFaasExecutor) Configure(requestID string) {
 	of.reqID = requestID
+	of.requestLogger = of.Logger.With(hlog.F("flow", of.flowName), hlog.F("request", requestID))
+}
+
+// log returns the logger of the request
+func (of *OpenFaasExecutor) log() hlog.Logger {
+	logger := of.requestLogger
+	if logger == nil {
+		logger = of.Logger.With(hlog.F("flow", of.flowName))
+	}
+	return logger
 }
 
 func (of *OpenFaasExecutor) GetFlowName() string {
@@ -125,6 +190,13 @@ func (of *OpenFaasExecutor) GetFlowDefinition(pipeline *sdk.Pipeline, context *s
 	workflow := faasflow.GetWorkflow(pipeline)
 	faasflowContext := (*faasflow.Context)(context)
 	err := function.Define(workflow, faasflowContext)
+	if err != nil {
+		return err
+	}
+
+	if config.CheckDeterminism() {
+		err = checkDeterminism(of.log(), of.flowName, pipeline, context)
+	}
 	return err
 }
 
@@ -159,12 +231,14 @@ func (of *OpenFaasExecutor) GetReqAuthKey() (string, error) {
 	return key, keyErr
 }
 
+// MonitoringEnabled returns true if the events of the request have to be
+// reported to the event handler, the sdk doesn't report them otherwise.
+// The failure of a request is reported to the callback url and logged with
+// the ownership of the flow
 func (of *OpenFaasExecutor) MonitoringEnabled() bool {
-	tracing := os.Getenv("enable_tracing")
-	if strings.ToUpper(tracing) == "TRUE" {
-		return true
-	}
-	return false
+	faasHandler := of.EventHandler.(*eventhandler.FaasEventHandler)
+	return config.TracingEnabled() || config.CloudEventsSink() != "" || of.Debug || faasHandler.Hooks.Any() ||
+		of.CallbackURL != "" || eventhandler.FlowOwnership() != nil
 }
 
 func (of *OpenFaasExecutor) GetEventHandler() (sdk.EventHandler, error) {
@@ -188,15 +262,64 @@ func (of *OpenFaasExecutor) GetDataStore() (sdk.DataStore, error) {
 }
 
 func (of *OpenFaasExecutor) Init(request *runtime.Request) error {
+	if of.Logger == nil {
+		of.Logger = hlog.NewStdLogger(hlog.InfoLevel)
+	}
+	of.logger.Logger = of.Logger
+
 	of.gateway = config.GatewayURL()
 	of.flowName = request.FlowName
-	of.asyncURL = buildURL("http://"+of.gateway, "async-function", of.flowName)
+	of.asyncURL = config.QueueURL()
+	if of.asyncURL == "" {
+		of.asyncURL = buildURL("http://"+of.gateway, "async-function", of.flowName)
+	}
+	if of.QueueProvider == nil {
+		of.QueueProvider = &queue.HTTPQueue{URL: of.asyncURL}
+	}
+	if of.ForwardPacer != nil {
+		of.QueueProvider = &queue.PacedQueue{
+			Queue:      of.QueueProvider,
+			Pacer:      of.ForwardPacer,
+			MaxRetries: config.ForwardMaxRetries(),
+			MaxWait:    config.ForwardMaxWait(),
+			Logger:     of.Logger.With(hlog.F("flow", of.flowName)),
+		}
+	}
+	if of.ForwardInterceptor != nil {
+		of.QueueProvider = &queue.InterceptedQueue{Queue: of.QueueProvider, Interceptor: of.ForwardInterceptor}
+	}
+
+	of.CallbackURL = request.GetHeader("X-Faas-Flow-Callback-Url")
+
+	of.StartTime = time.Now()
+	if startTime := request.GetHeader(startTimeHeader); startTime != "" {
+		var err error
+		of.StartTime, err = time.Parse(time.RFC3339Nano, startTime)
+		if err != nil {
+			return fmt.Errorf("invalid %s header %q, error %v", startTimeHeader, startTime, err)
+		}
+	}
+
+	deadline := request.GetHeader(deadlineHeader)
+	if deadline != "" {
+		var err error
+		of.Deadline, err = time.Parse(time.RFC3339, deadline)
+		if err != nil {
+			return fmt.Errorf("invalid %s header %q, error %v", deadlineHeader, deadline, err)
+		}
+	} else if budget := config.FlowDeadline(); budget > 0 {
+		of.Deadline = time.Now().Add(budget)
+	}
 
-	callbackURL := request.GetHeader("X-Faas-Flow-Callback-Url")
-	of.CallbackURL = callbackURL
+	// debug mode logs the payloads, it must be allowed by the flow
+	of.Debug = config.DebugHeaderAllowed() && strings.ToUpper(request.GetHeader(debugHeader)) == "TRUE"
 
 	faasHandler := of.EventHandler.(*eventhandler.FaasEventHandler)
 	faasHandler.Header = request.Header
+	faasHandler.Debug = of.Debug
+	faasHandler.Logger = of.Logger
+	faasHandler.StartTime = of.StartTime
+	faasHandler.OnFailure = of.handleExecutionFailure
 
 	return nil
 }
@@ -5,34 +5,78 @@ import (
 	"github.com/faasflow/runtime"
 	sdk "github.com/faasflow/sdk"
 	"github.com/faasflow/sdk/executor"
+	"handler/config"
 	"handler/eventhandler"
+	"handler/foreach"
+	"handler/hooks"
+	hlog "handler/log"
+	"handler/queue"
+	"handler/refdata"
+	"handler/store"
+	"time"
 )
 
 type OpenFaasRuntime struct {
-	stateStore   sdk.StateStore
-	dataStore    sdk.DataStore
-	eventHandler sdk.EventHandler
+	stateStore         sdk.StateStore
+	dataStore          sdk.DataStore
+	hooks              *hooks.Hooks
+	queueProvider      queue.QueueProvider
+	forwardInterceptor queue.ForwardInterceptor
+	forwardPacer       *queue.Pacer
+	logger             hlog.Logger
 }
 
 func (ofRuntime *OpenFaasRuntime) Init() error {
 	var err error
-	ofRuntime.stateStore, err = initStateStore()
+	ofRuntime.logger, err = initLogger()
+	if err != nil {
+		return fmt.Errorf("Failed to initialize the Logger, %v", err)
+	}
+	foreach.SetLogger(ofRuntime.logger)
+	refdata.SetLogger(ofRuntime.logger)
+
+	ofRuntime.stateStore, err = initStateStore(ofRuntime.logger)
 	if err != nil {
 		return fmt.Errorf("Failed to initialize the StateStore, %v", err)
 	}
 
-	ofRuntime.dataStore, err = initDataStore()
+	ofRuntime.dataStore, err = initDataStore(ofRuntime.logger)
 	if err != nil {
 		return fmt.Errorf("Failed to initialize the StateStore, %v", err)
 	}
 
-	ofRuntime.eventHandler = &eventhandler.FaasEventHandler{}
+	ofRuntime.queueProvider, err = initQueueProvider(ofRuntime.logger)
+	if err != nil {
+		return fmt.Errorf("Failed to initialize the QueueProvider, %v", err)
+	}
+
+	ofRuntime.forwardInterceptor, err = initForwardInterceptor()
+	if err != nil {
+		return fmt.Errorf("Failed to initialize the ForwardInterceptor, %v", err)
+	}
+
+	ofRuntime.forwardPacer = queue.NewPacer(100*time.Millisecond, config.ForwardMaxDelay())
+
+	ofRuntime.hooks, err = initHooks()
+	if err != nil {
+		return fmt.Errorf("Failed to initialize the Hooks, %v", err)
+	}
 
 	return nil
 }
 
 func (ofRuntime *OpenFaasRuntime) CreateExecutor(request *runtime.Request) (executor.Executor, error) {
-	ex := &OpenFaasExecutor{StateStore: ofRuntime.stateStore, DataStore: ofRuntime.dataStore, EventHandler: ofRuntime.eventHandler}
+	// the event handler holds the state of the request, one is created per executor
+	eventHandler := &eventhandler.FaasEventHandler{Hooks: ofRuntime.hooks}
+	// request scoped stores hold the request they are configured for
+	stateStore := store.StateStoreForRequest(ofRuntime.stateStore)
+	dataStore := store.DataStoreForRequest(ofRuntime.dataStore)
+	if ofRuntime.hooks.OnStatePersist != nil {
+		dataStore = &hookedDataStore{DataStore: dataStore, hooks: ofRuntime.hooks, startTime: time.Now()}
+	}
+	ex := &OpenFaasExecutor{StateStore: stateStore, DataStore: dataStore, EventHandler: eventHandler,
+		QueueProvider: ofRuntime.queueProvider, ForwardInterceptor: ofRuntime.forwardInterceptor,
+		ForwardPacer: ofRuntime.forwardPacer, Logger: ofRuntime.logger}
 	error := ex.Init(request)
 	return ex, error
 }
@@ -0,0 +1,36 @@
+package openfaas
+
+import (
+	"net/http"
+
+	"handler/config"
+	"handler/eventhandler"
+	"handler/queue"
+)
+
+// stampRequest sets the metadata of the flow configured with stamp_headers
+// on an outgoing request so that it can be attributed to the flow
+func (of *OpenFaasExecutor) stampRequest(header http.Header) {
+	for _, stamp := range config.StampHeaders() {
+		switch stamp {
+		case "user-agent":
+			header.Set("User-Agent", "faas-flow/"+of.flowName)
+		case "flow":
+			header.Set("X-Faas-Flow-Name", of.flowName)
+		case "request":
+			header.Set("X-Faas-Flow-Reqid", of.reqID)
+		case "node":
+			faasHandler := of.EventHandler.(*eventhandler.FaasEventHandler)
+			if faasHandler.CurrentNodeID != "" {
+				header.Set("X-Faas-Flow-Node", faasHandler.CurrentNodeID)
+			}
+		case "attempt":
+			// incremented by the PacedQueue when the call is retried
+			header.Set(queue.AttemptHeader, "1")
+		case "version":
+			if version := config.FlowVersion(); version != "" {
+				header.Set("X-Faas-Flow-Version", version)
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package queue
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// implements QueueProvider
+// HTTPQueue forwards partial states to a HTTP endpoint that queues the call
+// to the flow, such as the OpenFaaS gateway async-function endpoint
+type HTTPQueue struct {
+	URL string // the async URL of the flow
+}
+
+func (q *HTTPQueue) Enqueue(requestID string, state []byte, header http.Header) error {
+	forwardURL, err := ForwardURL(q.URL, requestID)
+	if err != nil {
+		return err
+	}
+
+	httpReq, _ := http.NewRequest(http.MethodPost, forwardURL, bytes.NewReader(state))
+	for key, values := range header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+
+	client := &http.Client{}
+	res, resErr := client.Do(httpReq)
+	if resErr != nil {
+		return resErr
+	}
+
+	defer res.Body.Close()
+	resData, _ := ioutil.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusAccepted {
+		return &StatusError{StatusCode: res.StatusCode, Body: string(resData)}
+	}
+	return nil
+}
+
+// ForwardURL returns the URL the partial state of a request is posted to for
+// the async URL of the flow
+func ForwardURL(asyncURL string, requestID string) (string, error) {
+	u, err := url.Parse(asyncURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid queue url %s, error %v", asyncURL, err)
+	}
+	u.Path = path.Join(u.Path, "flow", requestID, "forward")
+	return u.String(), nil
+}
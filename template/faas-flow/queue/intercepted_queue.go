@@ -0,0 +1,26 @@
+package queue
+
+import (
+	"net/http"
+)
+
+// ForwardInterceptor is called before a partial state is enqueued. It can
+// veto the call by returning an error, delay it, or return a transformed
+// state. The header can be modified in place
+type ForwardInterceptor func(requestID string, state []byte, header http.Header) ([]byte, error)
+
+// implements QueueProvider
+// InterceptedQueue passes each partial state through an Interceptor before
+// enqueueing it to Queue
+type InterceptedQueue struct {
+	Queue       QueueProvider
+	Interceptor ForwardInterceptor
+}
+
+func (q *InterceptedQueue) Enqueue(requestID string, state []byte, header http.Header) error {
+	state, err := q.Interceptor(requestID, state, header)
+	if err != nil {
+		return err
+	}
+	return q.Queue.Enqueue(requestID, state, header)
+}
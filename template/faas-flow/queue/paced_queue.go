@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	hlog "handler/log"
+)
+
+// AttemptHeader carries the attempt of an async call, it is updated on retries
+// when set
+const AttemptHeader = "X-Faas-Flow-Attempt"
+
+// implements QueueProvider
+// PacedQueue paces the calls to a queue with a Pacer, calls throttled by
+// the queue are retried instead of failing the request. The pacing delays
+// are spent in the current invocation, MaxWait bounds their total so that
+// the invocation ends before its timeout
+type PacedQueue struct {
+	Queue      QueueProvider
+	Pacer      *Pacer
+	MaxRetries int
+	MaxWait    time.Duration // zero for no bound
+	Logger     hlog.Logger
+}
+
+func (q *PacedQueue) Enqueue(requestID string, state []byte, header http.Header) error {
+	var waited time.Duration
+	var throttledErr error
+	for attempt := 0; ; attempt++ {
+		delay := q.Pacer.Delay()
+		if q.MaxWait > 0 && waited+delay > q.MaxWait {
+			// fail fast rather than outlasting the invocation
+			if throttledErr != nil {
+				return throttledErr
+			}
+			delay = q.MaxWait - waited
+		}
+		if delay > 0 {
+			time.Sleep(delay)
+			waited += delay
+		}
+		if attempt > 0 && header.Get(AttemptHeader) != "" {
+			header.Set(AttemptHeader, strconv.Itoa(attempt+1))
+		}
+
+		err := q.Queue.Enqueue(requestID, state, header)
+		if err == nil {
+			q.Pacer.Accepted()
+			return nil
+		}
+		if !IsThrottled(err) || attempt >= q.MaxRetries {
+			return err
+		}
+
+		throttledErr = err
+		delay = q.Pacer.Throttled()
+		if q.Logger != nil {
+			q.Logger.Warn("async call throttled, retrying with pacing delay",
+				hlog.F("request", requestID), hlog.F("error", err), hlog.F("delay", delay))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// throttledQueue throttles the first calls
+type throttledQueue struct {
+	throttled int
+	calls     int
+}
+
+func (q *throttledQueue) Enqueue(requestID string, state []byte, header http.Header) error {
+	q.calls++
+	if q.calls <= q.throttled {
+		return &StatusError{StatusCode: http.StatusTooManyRequests}
+	}
+	return nil
+}
+
+func TestPacedQueueRetriesThrottledCalls(t *testing.T) {
+	base := &throttledQueue{throttled: 2}
+	q := &PacedQueue{Queue: base, Pacer: NewPacer(time.Millisecond, 10*time.Millisecond), MaxRetries: 5}
+	header := http.Header{}
+	header.Set(AttemptHeader, "1")
+
+	if err := q.Enqueue("request", nil, header); err != nil {
+		t.Fatalf("expected the call to be retried, error %v", err)
+	}
+	if base.calls != 3 {
+		t.Errorf("expected 3 calls, got %d", base.calls)
+	}
+	if attempt := header.Get(AttemptHeader); attempt != "3" {
+		t.Errorf("expected attempt 3, got %s", attempt)
+	}
+}
+
+func TestPacedQueueMaxWait(t *testing.T) {
+	base := &throttledQueue{throttled: 100}
+	q := &PacedQueue{Queue: base, Pacer: NewPacer(20*time.Millisecond, time.Second), MaxRetries: 100,
+		MaxWait: 50 * time.Millisecond}
+
+	start := time.Now()
+	err := q.Enqueue("request", nil, http.Header{})
+	if !IsThrottled(err) {
+		t.Fatalf("expected a throttled error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("expected to fail fast, took %s", elapsed)
+	}
+}
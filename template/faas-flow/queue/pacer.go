@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// Pacer paces calls with an adaptive delay, the delay is doubled each time
+// the queue throttles a call, halved for each accepted call and decays with
+// the time elapsed since the last update so that an idle replica recovers
+type Pacer struct {
+	mutex    sync.Mutex
+	delay    time.Duration
+	step     time.Duration
+	maxDelay time.Duration
+	updated  time.Time
+	now      func() time.Time
+}
+
+// NewPacer creates a Pacer which paces by step up to maxDelay
+func NewPacer(step time.Duration, maxDelay time.Duration) *Pacer {
+	return &Pacer{step: step, maxDelay: maxDelay, now: time.Now}
+}
+
+// Delay return the current delay before a call
+func (pacer *Pacer) Delay() time.Duration {
+	pacer.mutex.Lock()
+	defer pacer.mutex.Unlock()
+
+	pacer.decay()
+	return pacer.delay
+}
+
+// Throttled increases the delay after a call has been throttled
+func (pacer *Pacer) Throttled() time.Duration {
+	pacer.mutex.Lock()
+	defer pacer.mutex.Unlock()
+
+	pacer.decay()
+	if pacer.delay == 0 {
+		pacer.delay = pacer.step
+	} else {
+		pacer.delay *= 2
+	}
+	if pacer.delay > pacer.maxDelay {
+		pacer.delay = pacer.maxDelay
+	}
+	return pacer.delay
+}
+
+// Accepted decreases the delay after a call has been accepted
+func (pacer *Pacer) Accepted() time.Duration {
+	pacer.mutex.Lock()
+	defer pacer.mutex.Unlock()
+
+	pacer.decay()
+	pacer.delay /= 2
+	if pacer.delay < pacer.step {
+		pacer.delay = 0
+	}
+	return pacer.delay
+}
+
+// decay decreases the delay by the time elapsed since the last update
+func (pacer *Pacer) decay() {
+	now := pacer.now()
+	if !pacer.updated.IsZero() {
+		pacer.delay -= now.Sub(pacer.updated)
+		if pacer.delay < 0 {
+			pacer.delay = 0
+		}
+	}
+	pacer.updated = now
+}
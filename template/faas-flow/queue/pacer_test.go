@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestPacer(now *time.Time) *Pacer {
+	pacer := NewPacer(100*time.Millisecond, 10*time.Second)
+	pacer.now = func() time.Time { return *now }
+	return pacer
+}
+
+func TestPacerThrottled(t *testing.T) {
+	now := time.Now()
+	pacer := newTestPacer(&now)
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}
+	for _, delay := range expected {
+		if got := pacer.Throttled(); got != delay {
+			t.Errorf("expected delay %s, got %s", delay, got)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		pacer.Throttled()
+	}
+	if got := pacer.Delay(); got != 10*time.Second {
+		t.Errorf("expected delay to be capped at 10s, got %s", got)
+	}
+}
+
+func TestPacerAccepted(t *testing.T) {
+	now := time.Now()
+	pacer := newTestPacer(&now)
+	for i := 0; i < 10; i++ {
+		pacer.Throttled()
+	}
+
+	if got := pacer.Accepted(); got != 5*time.Second {
+		t.Errorf("expected delay 5s, got %s", got)
+	}
+	for i := 0; i < 10; i++ {
+		pacer.Accepted()
+	}
+	if got := pacer.Delay(); got != 0 {
+		t.Errorf("expected no delay, got %s", got)
+	}
+}
+
+func TestPacerDecay(t *testing.T) {
+	now := time.Now()
+	pacer := newTestPacer(&now)
+	for i := 0; i < 10; i++ {
+		pacer.Throttled()
+	}
+
+	now = now.Add(4 * time.Second)
+	if got := pacer.Delay(); got != 6*time.Second {
+		t.Errorf("expected delay 6s, got %s", got)
+	}
+	now = now.Add(time.Minute)
+	if got := pacer.Delay(); got != 0 {
+		t.Errorf("expected no delay after idle, got %s", got)
+	}
+}
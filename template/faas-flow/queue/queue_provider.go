@@ -0,0 +1,13 @@
+package queue
+
+import (
+	"net/http"
+)
+
+// QueueProvider hands over the partial state of a request to the queue
+// which invokes the next execution of the flow
+type QueueProvider interface {
+	// Enqueue the encoded partial state of a request along with the
+	// headers of the forward request
+	Enqueue(requestID string, state []byte, header http.Header) error
+}
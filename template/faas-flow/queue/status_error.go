@@ -0,0 +1,28 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// StatusError is returned when the queue rejects a call with a HTTP status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("%d: %s", err.StatusCode, err.Body)
+}
+
+// IsThrottled check if the queue rejected a call as it is overloaded
+// (429 Too Many Requests or 503 Service Unavailable)
+func IsThrottled(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.StatusCode == http.StatusTooManyRequests ||
+		statusErr.StatusCode == http.StatusServiceUnavailable
+}
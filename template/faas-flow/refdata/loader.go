@@ -0,0 +1,109 @@
+package refdata
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	hlog "handler/log"
+)
+
+// logger logs the errors of the package, it is set by the runtime
+var logger hlog.Logger = hlog.NewStdLogger(hlog.InfoLevel)
+
+// SetLogger sets the logger of the package, it must be called before the
+// flow is executed
+func SetLogger(l hlog.Logger) {
+	logger = l
+}
+
+// loader loads a reference dataset once per replica and reloads it after ttl
+type loader struct {
+	ttl      time.Duration
+	load     func() ([]byte, error)
+	mutex    sync.Mutex
+	data     []byte
+	loadedAt time.Time
+}
+
+var (
+	loadersMutex sync.Mutex
+	loaders      = make(map[string]*loader)
+	// client fetches the datasets registered by url, the timeout bounds the
+	// time the requests of a dataset wait for a load
+	client = &http.Client{Timeout: 30 * time.Second}
+)
+
+// Register registers a reference dataset fetched with a GET from url and
+// reloaded after ttl (zero to never reload). Registering an existing name
+// has no effect, so it is safe to call from Define()
+func Register(name string, url string, ttl time.Duration) {
+	RegisterFunc(name, ttl, func() ([]byte, error) {
+		return fetchURL(url)
+	})
+}
+
+// RegisterFunc registers a reference dataset loaded with load, e.g. from a
+// DataStore, and reloaded after ttl (zero to never reload)
+func RegisterFunc(name string, ttl time.Duration, load func() ([]byte, error)) {
+	loadersMutex.Lock()
+	defer loadersMutex.Unlock()
+
+	if _, ok := loaders[name]; ok {
+		return
+	}
+	loaders[name] = &loader{ttl: ttl, load: load}
+}
+
+// Get returns a reference dataset, it is loaded on first use and reloaded
+// once expired. If a reload fails the previous data is returned.
+// The returned data is shared by all requests of the replica and must not be
+// modified, copy it first if needed
+func Get(name string) ([]byte, error) {
+	loadersMutex.Lock()
+	l, ok := loaders[name]
+	loadersMutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("reference data %s is not registered", name)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.data != nil && (l.ttl == 0 || time.Since(l.loadedAt) < l.ttl) {
+		return l.data, nil
+	}
+
+	data, err := l.load()
+	if err != nil {
+		if l.data != nil {
+			logger.Warn("failed to reload reference data, using previous data", hlog.F("name", name),
+				hlog.F("error", err))
+			return l.data, nil
+		}
+		return nil, fmt.Errorf("failed to load reference data %s, error %v", name, err)
+	}
+
+	l.data = data
+	l.loadedAt = time.Now()
+	return l.data, nil
+}
+
+func fetchURL(url string) ([]byte, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%d: %s", res.StatusCode, string(data))
+	}
+	return data, nil
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/faasflow/sdk"
+)
+
+const (
+	inlineValue   byte = 0
+	manifestValue byte = 1
+)
+
+// chunkedMagic prefixes the values written by ChunkedDataStore (format
+// version 1), values without it were written without chunking
+var chunkedMagic = []byte{'f', 'f', 'c', 1}
+
+// manifestSize is the size of a manifest: magic | type | generation (8 bytes) | count (4 bytes)
+const manifestSize = 4 + 1 + 8 + 4
+
+// implements faasflow.DataStore
+// ChunkedDataStore splits values larger than ChunkSize into chunks stored
+// under <key>/chunk-<generation>-<n>, the value of key then holds a manifest
+// with the generation and the number of chunks. Values written before
+// chunking was enabled are read as they are
+type ChunkedDataStore struct {
+	DataStore sdk.DataStore
+	ChunkSize int
+}
+
+// NewChunkedDataStore wraps dataStore with chunking of values larger than
+// chunkSize
+func NewChunkedDataStore(dataStore sdk.DataStore, chunkSize int) *ChunkedDataStore {
+	return &ChunkedDataStore{DataStore: dataStore, ChunkSize: chunkSize}
+}
+
+func (ds *ChunkedDataStore) ForRequest() sdk.DataStore {
+	return &ChunkedDataStore{DataStore: DataStoreForRequest(ds.DataStore), ChunkSize: ds.ChunkSize}
+}
+
+func (ds *ChunkedDataStore) Configure(flowName string, requestID string) {
+	ds.DataStore.Configure(flowName, requestID)
+}
+
+func (ds *ChunkedDataStore) Init() error {
+	return ds.DataStore.Init()
+}
+
+// Set stores small values inline. Large values are stored as new chunks
+// before the manifest is replaced, then the chunks of the previous value are
+// deleted, so a failed Set leaves the previous value readable. The chunks of
+// a previous value replaced by an inline value are left to the Cleanup of the
+// request to avoid reading the previous value on every write
+func (ds *ChunkedDataStore) Set(key string, value []byte) error {
+	if len(value) < ds.ChunkSize {
+		stored := make([]byte, 0, len(chunkedMagic)+1+len(value))
+		stored = append(stored, chunkedMagic...)
+		stored = append(stored, inlineValue)
+		return ds.DataStore.Set(key, append(stored, value...))
+	}
+
+	previous, previousErr := ds.manifest(key)
+
+	generation := uint64(time.Now().UnixNano())
+	count := 0
+	for start := 0; start < len(value); start += ds.ChunkSize {
+		end := start + ds.ChunkSize
+		if end > len(value) {
+			end = len(value)
+		}
+		err := ds.DataStore.Set(chunkKey(key, generation, count), value[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to set chunk %d of key %s, error %v", count, key, err)
+		}
+		count++
+	}
+
+	manifest := make([]byte, manifestSize)
+	copy(manifest, chunkedMagic)
+	manifest[4] = manifestValue
+	binary.BigEndian.PutUint64(manifest[5:], generation)
+	binary.BigEndian.PutUint32(manifest[13:], uint32(count))
+	if err := ds.DataStore.Set(key, manifest); err != nil {
+		return err
+	}
+
+	if previousErr == nil {
+		ds.delChunks(key, previous)
+	}
+	return nil
+}
+
+func (ds *ChunkedDataStore) Get(key string) ([]byte, error) {
+	stored, err := ds.DataStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(stored, chunkedMagic) {
+		// written before chunking was enabled
+		return stored, nil
+	}
+
+	if len(stored) == len(chunkedMagic) {
+		return nil, fmt.Errorf("failed to get key %s, missing value type", key)
+	}
+	switch stored[len(chunkedMagic)] {
+	case inlineValue:
+		return stored[len(chunkedMagic)+1:], nil
+	case manifestValue:
+		m, err := parseManifest(stored)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get key %s, error %v", key, err)
+		}
+		value := make([]byte, 0, m.count*ds.ChunkSize)
+		for index := 0; index < m.count; index++ {
+			chunk, err := ds.DataStore.Get(chunkKey(key, m.generation, index))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get chunk %d of key %s, error %v", index, key, err)
+			}
+			value = append(value, chunk...)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("failed to get key %s, unknown value type %d", key, stored[len(chunkedMagic)])
+	}
+}
+
+func (ds *ChunkedDataStore) Del(key string) error {
+	if m, err := ds.manifest(key); err == nil {
+		ds.delChunks(key, m)
+	}
+	return ds.DataStore.Del(key)
+}
+
+func (ds *ChunkedDataStore) Cleanup() error {
+	return ds.DataStore.Cleanup()
+}
+
+// chunkManifest locates the chunks of a value
+type chunkManifest struct {
+	generation uint64
+	count      int
+}
+
+// manifest returns the manifest of key, an error if key is not chunked
+func (ds *ChunkedDataStore) manifest(key string) (chunkManifest, error) {
+	stored, err := ds.DataStore.Get(key)
+	if err != nil {
+		return chunkManifest{}, err
+	}
+	return parseManifest(stored)
+}
+
+// delChunks deletes the chunks of a manifest
+func (ds *ChunkedDataStore) delChunks(key string, m chunkManifest) {
+	for index := 0; index < m.count; index++ {
+		ds.DataStore.Del(chunkKey(key, m.generation, index))
+	}
+}
+
+func parseManifest(stored []byte) (chunkManifest, error) {
+	if len(stored) != manifestSize || !bytes.HasPrefix(stored, chunkedMagic) || stored[4] != manifestValue {
+		return chunkManifest{}, fmt.Errorf("invalid chunk manifest")
+	}
+	return chunkManifest{
+		generation: binary.BigEndian.Uint64(stored[5:]),
+		count:      int(binary.BigEndian.Uint32(stored[13:])),
+	}, nil
+}
+
+func chunkKey(key string, generation uint64, index int) string {
+	return fmt.Sprintf("%s/chunk-%x-%d", key, generation, index)
+}
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newTestChunkedDataStore() (*ChunkedDataStore, *MemoryDataStore) {
+	base := NewMemoryDataStore()
+	ds := NewChunkedDataStore(base, 4)
+	ds.Configure("flow", "request")
+	return ds, base
+}
+
+// chunkCount returns the number of chunks of key in the memory store
+func chunkCount(base *MemoryDataStore, key string) int {
+	count := 0
+	for stored := range base.values {
+		if strings.Contains(stored, "/"+key+"/chunk-") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestChunkedDataStoreRoundTrip(t *testing.T) {
+	ds, base := newTestChunkedDataStore()
+
+	values := map[string][]byte{
+		"small": []byte("01"),
+		"large": []byte("0123456789"),
+		"empty": {},
+	}
+	for key, value := range values {
+		if err := ds.Set(key, value); err != nil {
+			t.Fatalf("failed to set %s, error %v", key, err)
+		}
+		got, err := ds.Get(key)
+		if err != nil {
+			t.Fatalf("failed to get %s, error %v", key, err)
+		}
+		if !bytes.Equal(got, value) {
+			t.Errorf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+	if count := chunkCount(base, "large"); count != 3 {
+		t.Errorf("expected 3 chunks, got %d", count)
+	}
+}
+
+func TestChunkedDataStoreOverwriteAndDel(t *testing.T) {
+	ds, base := newTestChunkedDataStore()
+
+	ds.Set("key", []byte("0123456789"))
+	ds.Set("key", []byte("abcdefgh"))
+	if count := chunkCount(base, "key"); count != 2 {
+		t.Errorf("expected the chunks of the previous value to be deleted, got %d chunks", count)
+	}
+	if got, _ := ds.Get("key"); string(got) != "abcdefgh" {
+		t.Errorf("expected \"abcdefgh\", got %q", got)
+	}
+
+	ds.Set("key", []byte("01"))
+	if got, _ := ds.Get("key"); string(got) != "01" {
+		t.Errorf("expected \"01\", got %q", got)
+	}
+
+	// the chunks of a value replaced by an inline value are left to Cleanup
+	if count := chunkCount(base, "key"); count != 2 {
+		t.Errorf("expected the previous chunks to be kept until cleanup, got %d chunks", count)
+	}
+
+	ds.Set("other", []byte("0123456789"))
+	if err := ds.Del("other"); err != nil {
+		t.Fatalf("failed to delete, error %v", err)
+	}
+	if count := chunkCount(base, "other"); count != 0 {
+		t.Errorf("expected the chunks to be deleted, got %d chunks", count)
+	}
+}
+
+func TestChunkedDataStoreMissingChunk(t *testing.T) {
+	ds, base := newTestChunkedDataStore()
+
+	ds.Set("key", []byte("0123456789"))
+	for stored := range base.values {
+		if strings.HasSuffix(stored, "-1") {
+			delete(base.values, stored)
+		}
+	}
+	if _, err := ds.Get("key"); err == nil {
+		t.Errorf("expected an error for a missing chunk")
+	}
+}
+
+func TestChunkedDataStoreLegacyValues(t *testing.T) {
+	ds, base := newTestChunkedDataStore()
+
+	// values written before chunking was enabled are read as they are
+	legacy := [][]byte{[]byte("plain value"), {0, 'a', 'b'}, {1, 0, 0, 0, 2}}
+	for index, value := range legacy {
+		key := fmt.Sprintf("legacy-%d", index)
+		base.Set(key, value)
+		got, err := ds.Get(key)
+		if err != nil {
+			t.Fatalf("failed to get %s, error %v", key, err)
+		}
+		if !bytes.Equal(got, value) {
+			t.Errorf("%s: expected %v, got %v", key, value, got)
+		}
+	}
+}
+
+// failingDataStore fails the writes of a key
+type failingDataStore struct {
+	*MemoryDataStore
+	failKey string
+}
+
+func (ds *failingDataStore) Set(key string, value []byte) error {
+	if key == ds.failKey {
+		return fmt.Errorf("failed to set %s", key)
+	}
+	return ds.MemoryDataStore.Set(key, value)
+}
+
+func TestChunkedDataStoreFailedSetKeepsPreviousValue(t *testing.T) {
+	base := &failingDataStore{MemoryDataStore: NewMemoryDataStore()}
+	ds := NewChunkedDataStore(base, 4)
+	ds.Configure("flow", "request")
+	ds.Set("key", []byte("0123456789"))
+
+	// the chunks are written, the manifest write fails
+	base.failKey = "key"
+	if err := ds.Set("key", []byte("abcdefghij")); err == nil {
+		t.Fatalf("expected the set to fail")
+	}
+
+	got, err := ds.Get("key")
+	if err != nil {
+		t.Fatalf("failed to get the previous value, error %v", err)
+	}
+	if string(got) != "0123456789" {
+		t.Errorf("expected the previous value, got %q", got)
+	}
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/faasflow/sdk"
+)
+
+const (
+	rawValue  byte = 0
+	gzipValue byte = 1
+)
+
+// implements faasflow.DataStore
+// CompressedDataStore gzips values of at least Threshold bytes before they
+// are stored in DataStore, each value is prefixed with its encoding
+type CompressedDataStore struct {
+	DataStore sdk.DataStore
+	Threshold int
+}
+
+// NewCompressedDataStore wraps dataStore with compression of values
+// larger than threshold
+func NewCompressedDataStore(dataStore sdk.DataStore, threshold int) *CompressedDataStore {
+	return &CompressedDataStore{DataStore: dataStore, Threshold: threshold}
+}
+
+func (ds *CompressedDataStore) ForRequest() sdk.DataStore {
+	return &CompressedDataStore{DataStore: DataStoreForRequest(ds.DataStore), Threshold: ds.Threshold}
+}
+
+func (ds *CompressedDataStore) Configure(flowName string, requestID string) {
+	ds.DataStore.Configure(flowName, requestID)
+}
+
+func (ds *CompressedDataStore) Init() error {
+	return ds.DataStore.Init()
+}
+
+func (ds *CompressedDataStore) Set(key string, value []byte) error {
+	if len(value) < ds.Threshold {
+		return ds.DataStore.Set(key, append([]byte{rawValue}, value...))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(gzipValue)
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(value); err != nil {
+		return fmt.Errorf("failed to compress key %s, error %v", key, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress key %s, error %v", key, err)
+	}
+	return ds.DataStore.Set(key, buf.Bytes())
+}
+
+func (ds *CompressedDataStore) Get(key string) ([]byte, error) {
+	stored, err := ds.DataStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return nil, fmt.Errorf("failed to decompress key %s, empty value", key)
+	}
+
+	switch stored[0] {
+	case rawValue:
+		return stored[1:], nil
+	case gzipValue:
+		reader, err := gzip.NewReader(bytes.NewReader(stored[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress key %s, error %v", key, err)
+		}
+		defer reader.Close()
+		value, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress key %s, error %v", key, err)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("failed to decompress key %s, unknown encoding %d", key, stored[0])
+	}
+}
+
+func (ds *CompressedDataStore) Del(key string) error {
+	return ds.DataStore.Del(key)
+}
+
+func (ds *CompressedDataStore) Cleanup() error {
+	return ds.DataStore.Cleanup()
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressedDataStoreRoundTrip(t *testing.T) {
+	base := NewMemoryDataStore()
+	ds := NewCompressedDataStore(base, 16)
+	ds.Configure("flow", "request")
+
+	values := map[string][]byte{
+		"small": []byte("short"),
+		"large": bytes.Repeat([]byte("compressible "), 100),
+		"empty": {},
+	}
+	for key, value := range values {
+		if err := ds.Set(key, value); err != nil {
+			t.Fatalf("failed to set %s, error %v", key, err)
+		}
+		got, err := ds.Get(key)
+		if err != nil {
+			t.Fatalf("failed to get %s, error %v", key, err)
+		}
+		if !bytes.Equal(got, value) {
+			t.Errorf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+
+	stored, _ := base.Get("large")
+	if stored[0] != gzipValue || len(stored) >= len(values["large"]) {
+		t.Errorf("large value is not compressed")
+	}
+	stored, _ = base.Get("small")
+	if stored[0] != rawValue {
+		t.Errorf("small value is compressed")
+	}
+}
+
+func TestCompressedDataStoreUnknownEncoding(t *testing.T) {
+	base := NewMemoryDataStore()
+	ds := NewCompressedDataStore(base, 16)
+	ds.Configure("flow", "request")
+	base.Set("key", []byte{9, 1, 2})
+
+	if _, err := ds.Get("key"); err == nil {
+		t.Errorf("expected an error for an unknown encoding")
+	}
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestStackedDataStores checks the wrappers in the order used by the runtime:
+// base, chunked, encrypted then compressed
+func TestStackedDataStores(t *testing.T) {
+	kms, err := NewLocalKMS(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("failed to create kms, error %v", err)
+	}
+	base := NewMemoryDataStore()
+	ds := NewCompressedDataStore(NewEncryptedDataStore(NewChunkedDataStore(base, 64), kms), 16)
+	ds.Configure("flow", "request")
+
+	values := map[string][]byte{
+		"small": []byte("short"),
+		"large": bytes.Repeat([]byte("compressible "), 100),
+	}
+	for key, value := range values {
+		if err := ds.Set(key, value); err != nil {
+			t.Fatalf("failed to set %s, error %v", key, err)
+		}
+		got, err := ds.Get(key)
+		if err != nil {
+			t.Fatalf("failed to get %s, error %v", key, err)
+		}
+		if !bytes.Equal(got, value) {
+			t.Errorf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+
+	if err := ds.Cleanup(); err != nil {
+		t.Fatalf("failed to cleanup, error %v", err)
+	}
+	if _, err := ds.Get("large"); err == nil {
+		t.Errorf("expected the values to be deleted by cleanup")
+	}
+}
+
+// TestStackedDataStoresForRequest checks that the request scoped instances
+// of stacked wrappers don't share the request
+func TestStackedDataStoresForRequest(t *testing.T) {
+	kms, _ := NewLocalKMS(bytes.Repeat([]byte{1}, 32))
+	shared := NewEncryptedDataStore(NewChunkedDataStore(NewMemoryDataStore(), 64), kms)
+
+	first := DataStoreForRequest(shared)
+	first.Configure("flow", "request-1")
+	second := DataStoreForRequest(shared)
+	second.Configure("flow", "request-2")
+
+	first.Set("key", []byte("value 1"))
+	second.Set("key", []byte("value 2"))
+
+	if got, err := first.Get("key"); err != nil || string(got) != "value 1" {
+		t.Errorf("expected \"value 1\", got %q (error %v)", got, err)
+	}
+	if got, err := second.Get("key"); err != nil || string(got) != "value 2" {
+		t.Errorf("expected \"value 2\", got %q (error %v)", got, err)
+	}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/faasflow/sdk"
+)
+
+// implements faasflow.DataStore
+// EncryptedDataStore encrypts values before they are stored in DataStore,
+// each request gets its own data key from the KMS and the encrypted data
+// key is stored along with every value. The flow, request and key are
+// authenticated with the value so that it can't be moved to another key
+// or request
+type EncryptedDataStore struct {
+	DataStore sdk.DataStore
+	KMS       KMS
+
+	mutex        sync.Mutex
+	keyPath      string
+	dataKey      []byte
+	encryptedKey []byte
+}
+
+// NewEncryptedDataStore wraps dataStore with encryption from kms
+func NewEncryptedDataStore(dataStore sdk.DataStore, kms KMS) *EncryptedDataStore {
+	return &EncryptedDataStore{DataStore: dataStore, KMS: kms}
+}
+
+// ForRequest returns an instance for a single request, as the data key and
+// the authenticated key path are those of the configured request
+func (ds *EncryptedDataStore) ForRequest() sdk.DataStore {
+	return &EncryptedDataStore{DataStore: DataStoreForRequest(ds.DataStore), KMS: ds.KMS}
+}
+
+func (ds *EncryptedDataStore) Configure(flowName string, requestID string) {
+	ds.mutex.Lock()
+	ds.keyPath = flowName + "/" + requestID
+	ds.dataKey = nil
+	ds.encryptedKey = nil
+	ds.mutex.Unlock()
+
+	ds.DataStore.Configure(flowName, requestID)
+}
+
+func (ds *EncryptedDataStore) Init() error {
+	return ds.DataStore.Init()
+}
+
+// Set encrypts the value, stored as: key length (2 bytes) | encrypted data key | nonce | encrypted value
+func (ds *EncryptedDataStore) Set(key string, value []byte) error {
+	dataKey, encryptedKey, err := ds.requestKey()
+	if err != nil {
+		return fmt.Errorf("failed to encrypt key %s, error %v", key, err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt key %s, error %v", key, err)
+	}
+	encrypted, err := seal(aead, value, ds.additionalData(key))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt key %s, error %v", key, err)
+	}
+
+	stored := make([]byte, 2, 2+len(encryptedKey)+len(encrypted))
+	binary.BigEndian.PutUint16(stored, uint16(len(encryptedKey)))
+	stored = append(stored, encryptedKey...)
+	stored = append(stored, encrypted...)
+
+	return ds.DataStore.Set(key, stored)
+}
+
+func (ds *EncryptedDataStore) Get(key string) ([]byte, error) {
+	stored, err := ds.DataStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stored) < 2 || len(stored) < 2+int(binary.BigEndian.Uint16(stored)) {
+		return nil, fmt.Errorf("failed to decrypt key %s, invalid value", key)
+	}
+	keyLen := int(binary.BigEndian.Uint16(stored))
+	encryptedKey, encrypted := stored[2:2+keyLen], stored[2+keyLen:]
+
+	dataKey, err := ds.KMS.DecryptDataKey(encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key of %s, error %v", key, err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key %s, error %v", key, err)
+	}
+	value, err := open(aead, encrypted, ds.additionalData(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key %s, error %v", key, err)
+	}
+	return value, nil
+}
+
+func (ds *EncryptedDataStore) Del(key string) error {
+	return ds.DataStore.Del(key)
+}
+
+func (ds *EncryptedDataStore) Cleanup() error {
+	return ds.DataStore.Cleanup()
+}
+
+// additionalData returns the data authenticated with the value of key
+func (ds *EncryptedDataStore) additionalData(key string) []byte {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	return []byte(ds.keyPath + "/" + key)
+}
+
+// requestKey returns the data key of the current request, generating it on first use
+func (ds *EncryptedDataStore) requestKey() ([]byte, []byte, error) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.dataKey == nil {
+		var err error
+		ds.dataKey, ds.encryptedKey, err = ds.KMS.GenerateDataKey()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return ds.dataKey, ds.encryptedKey, nil
+}
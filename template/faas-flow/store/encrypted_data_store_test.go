@@ -0,0 +1,77 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newTestEncryptedDataStore(t *testing.T) (*EncryptedDataStore, *MemoryDataStore) {
+	kms, err := NewLocalKMS(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("failed to create kms, error %v", err)
+	}
+	base := NewMemoryDataStore()
+	ds := NewEncryptedDataStore(base, kms)
+	ds.Configure("flow", "request-1")
+	return ds, base
+}
+
+func TestEncryptedDataStoreRoundTrip(t *testing.T) {
+	ds, base := newTestEncryptedDataStore(t)
+	value := []byte("secret value")
+
+	if err := ds.Set("key", value); err != nil {
+		t.Fatalf("failed to set, error %v", err)
+	}
+	stored, _ := base.Get("key")
+	if bytes.Contains(stored, value) {
+		t.Errorf("value is stored in clear")
+	}
+
+	got, err := ds.Get("key")
+	if err != nil {
+		t.Fatalf("failed to get, error %v", err)
+	}
+	if !bytes.Equal(got, value) {
+		t.Errorf("expected %q, got %q", value, got)
+	}
+}
+
+func TestEncryptedDataStoreTamperedValue(t *testing.T) {
+	ds, base := newTestEncryptedDataStore(t)
+	ds.Set("key", []byte("secret value"))
+
+	stored, _ := base.Get("key")
+	stored[len(stored)-1] ^= 1
+	base.Set("key", stored)
+
+	if _, err := ds.Get("key"); err == nil {
+		t.Errorf("expected an error for a tampered value")
+	}
+}
+
+func TestEncryptedDataStoreSwappedKeys(t *testing.T) {
+	ds, base := newTestEncryptedDataStore(t)
+	ds.Set("a", []byte("value a"))
+	ds.Set("b", []byte("value b"))
+
+	stored, _ := base.Get("b")
+	base.Set("a", stored)
+
+	if _, err := ds.Get("a"); err == nil {
+		t.Errorf("expected an error for a value moved to another key")
+	}
+}
+
+func TestEncryptedDataStoreSwappedRequests(t *testing.T) {
+	ds, base := newTestEncryptedDataStore(t)
+	ds.Set("key", []byte("value of request 1"))
+	stored, _ := base.Get("key")
+
+	ds.Configure("flow", "request-2")
+	base.Set("key", stored)
+
+	if _, err := ds.Get("key"); err == nil {
+		t.Errorf("expected an error for a value moved to another request")
+	}
+}
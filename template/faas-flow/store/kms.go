@@ -0,0 +1,75 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// KMS provides and unwraps the data keys used by EncryptedDataStore
+type KMS interface {
+	// GenerateDataKey returns a new data key along with its encrypted form
+	GenerateDataKey() (key []byte, encryptedKey []byte, err error)
+	// DecryptDataKey returns the data key of an encrypted data key
+	DecryptDataKey(encryptedKey []byte) ([]byte, error)
+}
+
+// implements KMS
+// LocalKMS wraps data keys with a master key held by the function
+type LocalKMS struct {
+	masterKey cipher.AEAD
+}
+
+// NewLocalKMS creates a LocalKMS from a 16, 24 or 32 bytes AES master key
+func NewLocalKMS(masterKey []byte) (*LocalKMS, error) {
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key, error %v", err)
+	}
+	return &LocalKMS{masterKey: aead}, nil
+}
+
+func (kms *LocalKMS) GenerateDataKey() ([]byte, []byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key, error %v", err)
+	}
+	encryptedKey, err := seal(kms.masterKey, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, encryptedKey, nil
+}
+
+func (kms *LocalKMS) DecryptDataKey(encryptedKey []byte) ([]byte, error) {
+	return open(kms.masterKey, encryptedKey, nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data authenticated with additionalData, the nonce is
+// prepended to the result
+func seal(aead cipher.AEAD, data []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce, error %v", err)
+	}
+	return aead.Seal(nonce, nonce, data, additionalData), nil
+}
+
+// open decrypts data produced by seal with the same additionalData
+func open(aead cipher.AEAD, data []byte, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt, data too short")
+	}
+	nonce, encrypted := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, encrypted, additionalData)
+}
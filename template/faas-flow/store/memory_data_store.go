@@ -0,0 +1,82 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/faasflow/sdk"
+)
+
+// implements faasflow.DataStore
+// MemoryDataStore keeps the data of the requests in memory, it is meant
+// for tests and single replica development only. It is request scoped, the
+// instances returned by ForRequest share the values
+type MemoryDataStore struct {
+	keyPath string
+	mutex   *sync.Mutex
+	values  map[string][]byte
+}
+
+// NewMemoryDataStore creates an empty MemoryDataStore
+func NewMemoryDataStore() *MemoryDataStore {
+	return &MemoryDataStore{
+		mutex:  &sync.Mutex{},
+		values: make(map[string][]byte),
+	}
+}
+
+func (ds *MemoryDataStore) ForRequest() sdk.DataStore {
+	return &MemoryDataStore{mutex: ds.mutex, values: ds.values}
+}
+
+func (ds *MemoryDataStore) Configure(flowName string, requestID string) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	ds.keyPath = "faasflow/" + flowName + "/" + requestID
+}
+
+func (ds *MemoryDataStore) Init() error {
+	return nil
+}
+
+func (ds *MemoryDataStore) Set(key string, value []byte) error {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	// keep a copy so the caller can reuse its buffer
+	ds.values[ds.keyPath+"/"+key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (ds *MemoryDataStore) Get(key string) ([]byte, error) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	value, ok := ds.values[ds.keyPath+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("failed to get key %s, not found", key)
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (ds *MemoryDataStore) Del(key string) error {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	delete(ds.values, ds.keyPath+"/"+key)
+	return nil
+}
+
+func (ds *MemoryDataStore) Cleanup() error {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	for key := range ds.values {
+		if strings.HasPrefix(key, ds.keyPath+"/") {
+			delete(ds.values, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/faasflow/sdk"
+)
+
+// implements faasflow.StateStore
+// MemoryStateStore keeps the state of the requests in memory, it is meant
+// for tests and single replica development only. It is request scoped, the
+// instances returned by ForRequest share the values
+type MemoryStateStore struct {
+	keyPath string
+	mutex   *sync.Mutex
+	values  map[string]string
+}
+
+// NewMemoryStateStore creates an empty MemoryStateStore
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		mutex:  &sync.Mutex{},
+		values: make(map[string]string),
+	}
+}
+
+func (ss *MemoryStateStore) ForRequest() sdk.StateStore {
+	return &MemoryStateStore{mutex: ss.mutex, values: ss.values}
+}
+
+func (ss *MemoryStateStore) Configure(flowName string, requestID string) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	ss.keyPath = "faasflow/" + flowName + "/" + requestID
+}
+
+func (ss *MemoryStateStore) Init() error {
+	return nil
+}
+
+func (ss *MemoryStateStore) Set(key string, value string) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	ss.values[ss.keyPath+"/"+key] = value
+	return nil
+}
+
+func (ss *MemoryStateStore) Get(key string) (string, error) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	value, ok := ss.values[ss.keyPath+"/"+key]
+	if !ok {
+		return "", fmt.Errorf("failed to get key %s, not found", key)
+	}
+	return value, nil
+}
+
+// Update sets the value of key only if its current value is oldValue
+func (ss *MemoryStateStore) Update(key string, oldValue string, newValue string) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	value, ok := ss.values[ss.keyPath+"/"+key]
+	if !ok {
+		return fmt.Errorf("failed to update key %s, not found", key)
+	}
+	if value != oldValue {
+		return fmt.Errorf("failed to update key %s, value mismatch", key)
+	}
+	ss.values[ss.keyPath+"/"+key] = newValue
+	return nil
+}
+
+func (ss *MemoryStateStore) Cleanup() error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	for key := range ss.values {
+		if strings.HasPrefix(key, ss.keyPath+"/") {
+			delete(ss.values, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMemoryStateStoreSetGet(t *testing.T) {
+	ss := NewMemoryStateStore()
+	ss.Configure("flow", "request")
+
+	if _, err := ss.Get("key"); err == nil {
+		t.Errorf("expected an error for a missing key")
+	}
+	ss.Set("key", "value")
+	if value, err := ss.Get("key"); err != nil || value != "value" {
+		t.Errorf("expected \"value\", got %q (error %v)", value, err)
+	}
+}
+
+func TestMemoryStateStoreUpdate(t *testing.T) {
+	ss := NewMemoryStateStore()
+	ss.Configure("flow", "request")
+
+	if err := ss.Update("key", "", "1"); err == nil {
+		t.Errorf("expected an error when updating a missing key")
+	}
+
+	ss.Set("key", "1")
+	if err := ss.Update("key", "0", "2"); err == nil {
+		t.Errorf("expected an error when the old value mismatch")
+	}
+	if value, _ := ss.Get("key"); value != "1" {
+		t.Errorf("expected value to be unchanged, got %q", value)
+	}
+
+	if err := ss.Update("key", "1", "2"); err != nil {
+		t.Errorf("failed to update, error %v", err)
+	}
+	if value, _ := ss.Get("key"); value != "2" {
+		t.Errorf("expected \"2\", got %q", value)
+	}
+}
+
+func TestMemoryStateStoreCleanup(t *testing.T) {
+	ss := NewMemoryStateStore()
+	ss.Configure("flow", "request-1")
+	ss.Set("key", "1")
+	ss.Configure("flow", "request-10")
+	ss.Set("key", "10")
+
+	ss.Configure("flow", "request-1")
+	if err := ss.Cleanup(); err != nil {
+		t.Fatalf("failed to cleanup, error %v", err)
+	}
+	if _, err := ss.Get("key"); err == nil {
+		t.Errorf("expected the key of request-1 to be deleted")
+	}
+
+	ss.Configure("flow", "request-10")
+	if value, err := ss.Get("key"); err != nil || value != "10" {
+		t.Errorf("expected the key of request-10 to be kept, got %q (error %v)", value, err)
+	}
+}
+
+func TestMemoryStateStoreConcurrentRequests(t *testing.T) {
+	shared := NewMemoryStateStore()
+
+	// each request uses its own instance, as the runtime does
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(requestID string) {
+			defer wg.Done()
+			ss := shared.ForRequest()
+			ss.Configure("flow", requestID)
+			ss.Set("owner", requestID)
+			for j := 0; j < 100; j++ {
+				if value, err := ss.Get("owner"); err != nil || value != requestID {
+					errs <- fmt.Errorf("request %s read %q (error %v)", requestID, value, err)
+					return
+				}
+			}
+			if err := ss.Update("owner", requestID, requestID+"-done"); err != nil {
+				errs <- fmt.Errorf("request %s failed to update, error %v", requestID, err)
+			}
+		}(fmt.Sprintf("request-%d", i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	ss := shared.ForRequest()
+	ss.Configure("flow", "request-3")
+	if value, _ := ss.Get("owner"); value != "request-3-done" {
+		t.Errorf("expected \"request-3-done\", got %q", value)
+	}
+}
+
+func TestMemoryStateStoreConcurrentUpdate(t *testing.T) {
+	shared := NewMemoryStateStore()
+	ss := shared.ForRequest()
+	ss.Configure("flow", "request")
+	ss.Set("counter", "0")
+
+	// only one of the concurrent updates from the same value succeeds
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	succeeded := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ss := shared.ForRequest()
+			ss.Configure("flow", "request")
+			if ss.Update("counter", "0", "1") == nil {
+				mutex.Lock()
+				succeeded++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Errorf("expected 1 successful update, got %d", succeeded)
+	}
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"github.com/faasflow/sdk"
+)
+
+// RequestScopedStateStore is implemented by a StateStore which holds the
+// request it is configured for, the runtime then uses an instance per request
+type RequestScopedStateStore interface {
+	// ForRequest returns an instance sharing the stored values, to be
+	// configured for a single request
+	ForRequest() sdk.StateStore
+}
+
+// RequestScopedDataStore is implemented by a DataStore which holds the
+// request it is configured for, the runtime then uses an instance per request
+type RequestScopedDataStore interface {
+	// ForRequest returns an instance sharing the stored values, to be
+	// configured for a single request
+	ForRequest() sdk.DataStore
+}
+
+// StateStoreForRequest returns an instance of stateStore for a single
+// request if it is request scoped, stateStore otherwise
+func StateStoreForRequest(stateStore sdk.StateStore) sdk.StateStore {
+	if scoped, ok := stateStore.(RequestScopedStateStore); ok {
+		return scoped.ForRequest()
+	}
+	return stateStore
+}
+
+// DataStoreForRequest returns an instance of dataStore for a single request
+// if it is request scoped, dataStore otherwise
+func DataStoreForRequest(dataStore sdk.DataStore) sdk.DataStore {
+	if scoped, ok := dataStore.(RequestScopedDataStore); ok {
+		return scoped.ForRequest()
+	}
+	return dataStore
+}